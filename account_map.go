@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+)
+
+var accountMapFile string
+
+// accountMap maps friendly names to SSO accounts and roles, read from an
+// --account-map file. The SSO session itself is configured in the AWS config
+// file as usual.
+type accountMap struct {
+	SSOSession string                  `json:"sso_session"`
+	Accounts   map[string]accountEntry `json:"accounts"`
+}
+
+type accountEntry struct {
+	AccountID string `json:"account_id"`
+	RoleName  string `json:"role_name"`
+	Region    string `json:"region,omitempty"`
+}
+
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// loadAccountMap reads and validates the account map at path.
+func loadAccountMap(path string) (*accountMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read account map: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var m accountMap
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("Invalid account map %s: %w", path, err)
+	}
+
+	if m.SSOSession == "" {
+		return nil, fmt.Errorf("Invalid account map %s: sso_session is required", path)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(m.Accounts)) {
+		a := m.Accounts[name]
+		if !accountIDPattern.MatchString(a.AccountID) {
+			return nil, fmt.Errorf("Invalid account map %s: accounts.%s: account_id %q must be 12 digits", path, name, a.AccountID)
+		}
+		if a.RoleName == "" {
+			return nil, fmt.Errorf("Invalid account map %s: accounts.%s: role_name is required", path, name)
+		}
+	}
+
+	return &m, nil
+}
+
+// accountMapOptions returns load options that get credentials for the named
+// account from SSO, logging in with the AWS CLI if there is no valid SSO
+// token yet. The SSO clients are configured with clientOpts.
+func accountMapOptions(ctx context.Context, path, name string, clientOpts []func(*config.LoadOptions) error) ([]func(*config.LoadOptions) error, error) {
+	m, err := loadAccountMap(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := m.Accounts[name]
+	if !ok {
+		msg := ""
+		if closest, ok := closestProfile(name, slices.Sorted(maps.Keys(m.Accounts))); ok {
+			msg = fmt.Sprintf("; did you mean %q?", closest)
+		}
+		return nil, fmt.Errorf("Account %q is not present in account map %s%s", name, path, msg)
+	}
+
+	contents, err := os.ReadFile(configFilePath())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read AWS config file: %w", err)
+	}
+
+	session := sectionValues(string(contents), "sso-session "+m.SSOSession)
+	if session == nil {
+		return nil, fmt.Errorf("SSO session %q from account map %s is not present in %s", m.SSOSession, path, configFilePath())
+	}
+	if session["sso_start_url"] == "" || session["sso_region"] == "" {
+		return nil, fmt.Errorf("SSO session %q must set sso_start_url and sso_region", m.SSOSession)
+	}
+
+	tokenPath, err := ssocreds.StandardCachedTokenFilepath(m.SSOSession)
+	if err != nil {
+		return nil, err
+	}
+
+	clientCfg, err := clientConfig(session["sso_region"], clientOpts)
+	if err != nil {
+		return nil, err
+	}
+	tokens := ssocreds.NewSSOTokenProvider(ssooidc.NewFromConfig(clientCfg), tokenPath)
+
+	if _, err := tokens.RetrieveBearerToken(ctx); err != nil {
+		if err := ssoLogin(ctx, m.SSOSession); err != nil {
+			return nil, err
+		}
+	}
+
+	provider := ssocreds.New(ssoTimeoutClient{sso.NewFromConfig(clientCfg)}, entry.AccountID, entry.RoleName, session["sso_start_url"], func(o *ssocreds.Options) {
+		o.SSOTokenProvider = tokens
+	})
+
+	opts := []func(*config.LoadOptions) error{
+		config.WithCredentialsProvider(aws.NewCredentialsCache(provider)),
+	}
+	if entry.Region != "" {
+		opts = append(opts, config.WithRegion(entry.Region))
+	}
+
+	return opts, nil
+}
+
+// ssoLogin runs `aws sso login` for the session. Its output goes to stderr,
+// so that it does not get mixed into output that is being evaluated.
+func ssoLogin(ctx context.Context, session string) error {
+	cmd := exec.CommandContext(ctx, "aws", "sso", "login", "--sso-session", session)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("No valid SSO token for session %q, and the AWS CLI is not installed to log in; run `aws sso login --sso-session %s`", session, session)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to log in to SSO session %q: %w", session, err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+)
+
+// TestAccountMapClientOptions checks that the SSO calls for an account map go
+// through the same endpoint and API options as every other AWS call.
+func TestAccountMapClientOptions(t *testing.T) {
+	f := newFakeAWS(t)
+
+	t.Setenv("HOME", f.home)
+	tokenPath, err := ssocreds.StandardCachedTokenFilepath("corp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(tokenPath), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, tokenPath, `{"accessToken": "fake-sso-access-token", "expiresAt": "2099-01-01T00:00:00Z", "region": "us-east-1", "startUrl": "https://corp.awsapps.com/start"}`)
+
+	mapFile := filepath.Join(f.home, "map.json")
+	writeTestFile(t, mapFile, `{"sso_session": "corp", "accounts": {"prod": {"account_id": "123456789012", "role_name": "Admin"}}}`)
+	traceFile := filepath.Join(f.home, "trace.json")
+
+	out, err := runCred(t, f, "--account-map", mapFile, "--profile", "prod", "--trace-out", traceFile)
+	if err != nil {
+		t.Fatalf("unexpected error %v, stderr:\n%s", err, out)
+	}
+
+	if got := f.called("GetRoleCredentials"); got != 1 {
+		t.Errorf("GetRoleCredentials was sent to --endpoint-url %d times, want 1", got)
+	}
+	trace, err := os.ReadFile(traceFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(trace), `"service":"SSO","operation":"GetRoleCredentials"`) {
+		t.Errorf("the trace does not include the SSO call:\n%s", trace)
+	}
+}
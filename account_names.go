@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+var (
+	accountNameMapFile string
+	noAccountName      bool
+)
+
+// accountName returns the friendly name of the account, from the
+// --account-name-map file or else cred's config file, or an empty string if
+// it has none. Names are only ever displayed; exported account IDs stay
+// numeric.
+func accountName(id string) string {
+	if noAccountName || id == "" {
+		return ""
+	}
+
+	if accountNameMapFile != "" {
+		names, err := loadAccountNames(accountNameMapFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "Warning: %v\n", err)
+			return ""
+		}
+		return names[id]
+	}
+
+	s, err := loadSettings()
+	if err != nil {
+		return ""
+	}
+	return s.AccountNames[id]
+}
+
+// loadAccountNames reads a JSON object that maps account IDs to names.
+func loadAccountNames(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read account name map: %w", err)
+	}
+
+	names := map[string]string{}
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("Invalid account name map %s: %w", path, err)
+	}
+	return names, nil
+}
+
+// displayAccount returns the account ID followed by its friendly name, if it
+// has one, e.g. 123456789012 (prod).
+func displayAccount(id string) string {
+	if n := accountName(id); n != "" {
+		return fmt.Sprintf("%s (%s)", id, n)
+	}
+	return id
+}
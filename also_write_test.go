@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAlsoWrite(t *testing.T) {
+	const wantErr = "Refusing to overwrite profile default with its own credentials"
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     string
+		wantErr string
+	}{
+		{name: "other profile", args: []string{"--also-write", "dst"}},
+		{name: "named profile", args: []string{"--also-write", "test"}, wantErr: "Refusing to overwrite profile test with its own credentials"},
+		{name: "default profile", args: []string{"--profile", "", "--also-write", "default"}, wantErr: wantErr},
+		{name: "environment profile", env: "default", args: []string{"--profile", "", "--also-write", "default"}, wantErr: wantErr},
+		{name: "fallback profile", args: []string{"--profile-fallback", "broken,default", "--also-write", "default"}, wantErr: wantErr},
+		{name: "invalid name", args: []string{"--also-write", "a b"}, wantErr: `Invalid --also-write profile name "a b"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeAWS(t)
+			if tt.env != "" {
+				// runCred clears AWS_PROFILE, so select it with CRED_PROFILE.
+				t.Setenv("CRED_PROFILE", tt.env)
+			}
+
+			out, err := runCred(t, f, tt.args...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v, stderr:\n%s", err, out)
+				}
+				data, err := os.ReadFile(credentialsFilePath())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(string(data), "[dst]") {
+					t.Errorf("the credentials file has no [dst] profile:\n%s", data)
+				}
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("got error %v, want %s", err, tt.wantErr)
+			}
+			if n := len(f.calls); n > 0 {
+				t.Errorf("got %d calls to AWS before the error, want none", n)
+			}
+		})
+	}
+}
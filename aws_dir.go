@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+)
+
+var awsDir string
+
+// awsDirOptions returns load options that read the config and credentials
+// files, and the SSO token cache, from the --aws-dir tree instead of ~/.aws.
+func awsDirOptions() ([]func(*config.LoadOptions) error, error) {
+	info, err := os.Stat(awsDir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read --aws-dir: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("Invalid --aws-dir %s: not a directory", awsDir)
+	}
+
+	return []func(*config.LoadOptions) error{
+		config.WithSharedConfigFiles([]string{filepath.Join(awsDir, "config")}),
+		config.WithSharedCredentialsFiles([]string{filepath.Join(awsDir, "credentials")}),
+		config.WithSSOTokenProviderOptions(func(o *ssocreds.SSOTokenProviderOptions) {
+			o.CachedTokenFilepath = filepath.Join(awsDir, "sso", "cache", filepath.Base(o.CachedTokenFilepath))
+		}),
+	}, nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+var (
+	showChain   bool
+	verifyChain bool
+)
+
+// credentialSource describes where a profile's own credentials come from.
+func credentialSource(c *config.SharedConfig) string {
+	switch {
+	case c.Credentials.HasKeys():
+		return "access keys"
+	case c.SSOSessionName != "" || c.SSOStartURL != "":
+		return "SSO"
+	case c.CredentialProcess != "":
+		return "credential_process"
+	case c.WebIdentityTokenFile != "":
+		return "web identity token"
+	case c.CredentialSource != "":
+		return c.CredentialSource
+	default:
+		return "SDK default chain"
+	}
+}
+
+// roleChain lists the steps cred will take to reach the final credentials,
+// from the profile's source credentials to the last role in the spec. It is
+// worked out from configuration alone, without calling AWS.
+func roleChain(cfg aws.Config, s *spec) []string {
+	steps := []string{}
+
+	for _, src := range cfg.ConfigSources {
+		shared, ok := src.(config.SharedConfig)
+		if !ok {
+			continue
+		}
+
+		profiles := []*config.SharedConfig{}
+		for p := &shared; p != nil; p = p.Source {
+			profiles = append(profiles, p)
+		}
+		slices.Reverse(profiles)
+
+		for i, p := range profiles {
+			if i == 0 {
+				steps = append(steps, fmt.Sprintf("profile %s (%s)", p.Profile, credentialSource(p)))
+			}
+			if p.RoleARN != "" {
+				steps = append(steps, fmt.Sprintf("%s (profile %s)", p.RoleARN, p.Profile))
+			}
+		}
+	}
+
+	if len(steps) == 0 {
+		steps = append(steps, "SDK default chain")
+	}
+
+	if s != nil {
+		for i, h := range s.Chain {
+			steps = append(steps, fmt.Sprintf("%s (spec chain[%d])", h.RoleARN, i))
+		}
+	}
+
+	return steps
+}
+
+func printRoleChain(w io.Writer, steps []string) {
+	fmt.Fprintln(w, "Role chain:")
+	for i, step := range steps {
+		if i == 0 {
+			fmt.Fprintf(w, "  %s\n", step)
+		} else {
+			fmt.Fprintf(w, "  -> %s\n", step)
+		}
+	}
+}
+
+// verifyProfileChain checks the credentials of each source_profile that the
+// selected profile's role chain passes through, from the first, by loading
+// the config of each one with opts. The selected profile itself is left to
+// the caller. A profile that needs MFA, and every profile after it, is
+// skipped, since reaching it again would need another MFA code.
+func verifyProfileChain(ctx context.Context, cfg aws.Config, opts []func(*config.LoadOptions) error) error {
+	profiles := []*config.SharedConfig{}
+	for _, src := range cfg.ConfigSources {
+		if shared, ok := src.(config.SharedConfig); ok {
+			for p := shared.Source; p != nil; p = p.Source {
+				profiles = append(profiles, p)
+			}
+		}
+	}
+	slices.Reverse(profiles)
+
+	for i, p := range profiles {
+		if p.MFASerial != "" {
+			for _, skipped := range profiles[i:] {
+				fmt.Fprintf(stderr, "  skipped profile %s: it needs another MFA code\n", skipped.Profile)
+			}
+			return nil
+		}
+
+		hopCfg, err := config.LoadDefaultConfig(ctx, append(slices.Clone(opts), config.WithSharedConfigProfile(p.Profile))...)
+		if err != nil {
+			return fmt.Errorf("Failed to load profile %s of the role chain: %w", p.Profile, err)
+		}
+		hopCfg.Credentials = redactCredentials(hopCfg.Credentials)
+
+		if err := verifyStep(ctx, hopCfg, "profile "+p.Profile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyStep checks the credentials of one step of the role chain with
+// GetCallerIdentity, and reports the ARN they resolve to on stderr.
+func verifyStep(ctx context.Context, cfg aws.Config, step string) error {
+	data, err := getCallerIdentity(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("Failed to verify %s of the role chain: %w", step, err)
+	}
+	fmt.Fprintf(stderr, "  verified %s: %s\n", step, aws.ToString(data.Arn))
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestVerifyProfileChain(t *testing.T) {
+	f := newFakeAWS(t)
+	var mu sync.Mutex
+	roles := []string{}
+	f.handle["AssumeRole"] = func(r *http.Request) (int, string) {
+		mu.Lock()
+		defer mu.Unlock()
+		roles = append(roles, r.Form.Get("RoleArn"))
+		return http.StatusOK, fakeResponses["AssumeRole"]
+	}
+
+	out, err := runCred(t, f, "--profile", "admin", "--verify-chain")
+	if err != nil {
+		t.Fatalf("unexpected error %v, stderr:\n%s", err, out)
+	}
+
+	for _, step := range []string{"profile test", "profile jump", "profile admin"} {
+		if !strings.Contains(out, "verified "+step+": ") {
+			t.Errorf("%s was not verified, stderr:\n%s", step, out)
+		}
+	}
+	if i, j := strings.Index(out, "verified profile test"), strings.Index(out, "verified profile jump"); i > j {
+		t.Errorf("profiles were not verified in order, stderr:\n%s", out)
+	}
+	if !slices.Contains(roles, "arn:aws:iam::123456789012:role/Jump") || !slices.Contains(roles, "arn:aws:iam::123456789012:role/Admin") {
+		t.Errorf("got AssumeRole calls for %v, want Jump and Admin", roles)
+	}
+}
+
+func TestVerifyProfileChainFailure(t *testing.T) {
+	f := newFakeAWS(t)
+	f.handle["AssumeRole"] = func(r *http.Request) (int, string) {
+		if strings.HasSuffix(r.Form.Get("RoleArn"), "role/Jump") {
+			return http.StatusForbidden, fakeError("AccessDenied", "Not authorized to assume Jump")
+		}
+		return http.StatusOK, fakeResponses["AssumeRole"]
+	}
+
+	out, err := runCred(t, f, "--profile", "admin", "--verify-chain")
+	if err == nil {
+		t.Fatalf("expected an error, stderr:\n%s", out)
+	}
+	if !strings.Contains(err.Error(), "profile jump") {
+		t.Errorf("the error does not name the failing profile: %v", err)
+	}
+	if !strings.Contains(out, "verified profile test: ") {
+		t.Errorf("profile test was not verified, stderr:\n%s", out)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"crypto/subtle"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// currentEnv captures the values of cred's variables as they are currently
+// exported, before cred clears them to resolve fresh credentials.
+func currentEnv() map[string]string {
+	env := map[string]string{}
+	for _, key := range allVars() {
+		env[key] = os.Getenv(name(key))
+	}
+	return env
+}
+
+// unchanged reports whether creds are the credentials already exported in
+// env. The secret access key is compared in constant time.
+func unchanged(env map[string]string, creds aws.Credentials) bool {
+	if env[accessKeyID] != creds.AccessKeyID {
+		return false
+	}
+
+	if subtle.ConstantTimeCompare([]byte(env[secretAccessKey]), []byte(creds.SecretAccessKey)) != 1 {
+		return false
+	}
+
+	if creds.SessionToken == "" {
+		return env[sessionToken] == ""
+	}
+
+	return env[sessionExpiresAt] == creds.Expires.Format(time.RFC3339)
+}
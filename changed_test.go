@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputOnlyIfChanged(t *testing.T) {
+	tests := []struct {
+		name           string
+		profile        string
+		env            map[string]string
+		wantOutput     bool
+		wantAssumeRole int
+	}{
+		{
+			name:    "static keys unchanged",
+			profile: "test",
+			env:     map[string]string{accessKeyID: fakeAccessKeyID, secretAccessKey: fakeSecret},
+		},
+		{
+			name:       "static keys changed",
+			profile:    "test",
+			env:        map[string]string{accessKeyID: "AKIDOTHER", secretAccessKey: fakeSecret},
+			wantOutput: true,
+		},
+		{
+			name:       "different secret",
+			profile:    "test",
+			env:        map[string]string{accessKeyID: fakeAccessKeyID, secretAccessKey: "other-secret"},
+			wantOutput: true,
+		},
+		{
+			// The role is still assumed to find out what its credentials are.
+			name:    "role unchanged",
+			profile: "jump",
+			env: map[string]string{
+				accessKeyID:      "ASIAFAKE",
+				secretAccessKey:  fakeRoleSecret,
+				sessionToken:     fakeRoleToken,
+				sessionExpiresAt: "2099-01-01T00:00:00Z",
+			},
+			wantAssumeRole: 1,
+		},
+		{
+			name:    "role expiry changed",
+			profile: "jump",
+			env: map[string]string{
+				accessKeyID:      "ASIAFAKE",
+				secretAccessKey:  fakeRoleSecret,
+				sessionToken:     fakeRoleToken,
+				sessionExpiresAt: "2098-01-01T00:00:00Z",
+			},
+			wantOutput:     true,
+			wantAssumeRole: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A prefix keeps the exported variables apart from the ones
+			// that runCred clears.
+			for key, value := range tt.env {
+				t.Setenv("DEV_"+key, value)
+			}
+
+			f := newFakeAWS(t)
+			out, err := runCred(t, f, "--prefix", "DEV_", "--profile", tt.profile, "--output-only-if-changed")
+			if err != nil {
+				t.Fatalf("unexpected error %v, stderr:\n%s", err, out)
+			}
+
+			stdout, err := os.ReadFile(filepath.Join(f.home, "stdout"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(stdout) > 0; got != tt.wantOutput {
+				t.Errorf("output: got %t, want %t:\n%s", got, tt.wantOutput, stdout)
+			}
+			if got, want := f.called("GetCallerIdentity") > 0, tt.wantOutput; got != want {
+				t.Errorf("GetCallerIdentity called: got %t, want %t", got, want)
+			}
+			if got := f.called("AssumeRole"); got != tt.wantAssumeRole {
+				t.Errorf("AssumeRole called %d times, want %d", got, tt.wantAssumeRole)
+			}
+		})
+	}
+}
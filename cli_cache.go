@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+var writeCLICache bool
+
+// cliCacheEntry is an assume-role session in the AWS CLI's cache, in the
+// shape of the AssumeRole response it saves.
+type cliCacheEntry struct {
+	Credentials cliCacheCredentials `json:"Credentials"`
+}
+
+type cliCacheCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken"`
+	Expiration      string `json:"Expiration"`
+}
+
+// cliCacheKey returns the name the AWS CLI and boto3 give to the cache file
+// for an assume-role profile: the SHA-1 of the AssumeRole arguments taken
+// from the profile, as Python's json.dumps(args, sort_keys=True) writes
+// them. The session name is left out, since it differs on every run.
+func cliCacheKey(p config.SharedConfig) string {
+	args := map[string]any{"RoleArn": p.RoleARN}
+	if p.ExternalID != "" {
+		args["ExternalId"] = p.ExternalID
+	}
+	if p.MFASerial != "" {
+		args["SerialNumber"] = p.MFASerial
+	}
+	if p.RoleDurationSeconds != nil {
+		args["DurationSeconds"] = int(p.RoleDurationSeconds.Seconds())
+	}
+
+	pairs := []string{}
+	for _, k := range slices.Sorted(maps.Keys(args)) {
+		key, _ := json.Marshal(k)
+		val, _ := json.Marshal(args[k])
+		pairs = append(pairs, fmt.Sprintf("%s: %s", key, val))
+	}
+
+	sum := sha1.Sum([]byte("{" + strings.Join(pairs, ", ") + "}"))
+	return hex.EncodeToString(sum[:])
+}
+
+func cliCacheDir() (string, error) {
+	if awsDir != "" {
+		return filepath.Join(awsDir, "cli", "cache"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("Failed to locate the AWS CLI cache: %w", err)
+	}
+	return filepath.Join(home, ".aws", "cli", "cache"), nil
+}
+
+// writeCLICacheEntry saves the resolved session in the AWS CLI's cache, so
+// that the CLI and boto3 use it for the profile instead of assuming the role
+// again. It only works for assume-role profiles, whose sessions the CLI
+// caches.
+func writeCLICacheEntry(res *resolution) error {
+	var shared *config.SharedConfig
+	for _, src := range res.cfg.ConfigSources {
+		if c, ok := src.(config.SharedConfig); ok {
+			shared = &c
+		}
+	}
+
+	switch {
+	case specFile != "":
+		return fmt.Errorf("--write-cli-cache cannot be used with --spec, since the AWS CLI does not know about its roles")
+	case shared == nil || shared.RoleARN == "":
+		return fmt.Errorf("--write-cli-cache only works for profiles that assume a role")
+	case !res.creds.CanExpire:
+		return fmt.Errorf("--write-cli-cache needs temporary credentials")
+	}
+
+	dir, err := cliCacheDir()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(cliCacheEntry{Credentials: cliCacheCredentials{
+		AccessKeyID:     res.creds.AccessKeyID,
+		SecretAccessKey: res.creds.SecretAccessKey,
+		SessionToken:    res.creds.SessionToken,
+		Expiration:      res.creds.Expires.UTC().Format(time.RFC3339),
+	}}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("Failed to write the AWS CLI cache: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, cliCacheKey(*shared)+".json"), append(data, '\n')); err != nil {
+		return fmt.Errorf("Failed to write the AWS CLI cache: %w", err)
+	}
+	return nil
+}
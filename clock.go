@@ -0,0 +1,26 @@
+package main
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// clock tells the time for cred's expiry calculations, so that they do not
+// depend on the real time where that is not wanted.
+type clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// now is the clock that expiry calculations use.
+var now clock = systemClock{}
+
+// expiresWithin reports whether creds expire less than d from now. Expired
+// credentials expire within any d.
+func expiresWithin(creds aws.Credentials, d time.Duration) bool {
+	return creds.CanExpire && creds.Expires.Sub(now.Now()) < d
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+var (
+	checkClock         bool
+	clockSkewThreshold time.Duration
+	clockSkewWarnOnly  bool
+)
+
+// checkClockSkew fails with --check-clock if the local clock and the Date of
+// the GetCallerIdentity response differ by more than --clock-skew-threshold.
+// AWS rejects requests signed with a clock that is too far off, and a skewed
+// clock makes temporary credentials appear to expire early or late.
+func checkClockSkew(data *sts.GetCallerIdentityOutput) error {
+	if !checkClock {
+		return nil
+	}
+
+	server, ok := awsmiddleware.GetServerTime(data.ResultMetadata)
+	local, localOK := awsmiddleware.GetResponseAt(data.ResultMetadata)
+	if !ok || !localOK {
+		fmt.Fprintln(stderr, "Warning: STS did not report its time, so the clock was not checked")
+		return nil
+	}
+
+	skew := local.Sub(server)
+	if skew.Abs() <= clockSkewThreshold {
+		return nil
+	}
+
+	direction := "ahead of"
+	if skew < 0 {
+		direction = "behind"
+	}
+	err := fmt.Errorf("The local clock is %s %s AWS's; sync your clock, e.g. with NTP", skew.Abs().Round(time.Second), direction)
+	if clockSkewWarnOnly {
+		fmt.Fprintf(stderr, "Warning: %s\n", err)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// fixedClock is a clock that is always at the same time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// setClock stops now at the time at for the rest of the test.
+func setClock(t *testing.T, at time.Time) {
+	t.Helper()
+	old := now
+	now = fixedClock(at)
+	t.Cleanup(func() { now = old })
+}
+
+func TestExpiresWithin(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	setClock(t, at)
+
+	tests := []struct {
+		name  string
+		creds aws.Credentials
+		want  bool
+	}{
+		{name: "far future", creds: aws.Credentials{CanExpire: true, Expires: at.Add(12 * time.Hour)}, want: false},
+		{name: "just outside", creds: aws.Credentials{CanExpire: true, Expires: at.Add(10 * time.Minute)}, want: false},
+		{name: "near expiry", creds: aws.Credentials{CanExpire: true, Expires: at.Add(9 * time.Minute)}, want: true},
+		{name: "expiring now", creds: aws.Credentials{CanExpire: true, Expires: at}, want: true},
+		{name: "expired", creds: aws.Credentials{CanExpire: true, Expires: at.Add(-time.Hour)}, want: true},
+		{name: "long-lived", creds: aws.Credentials{}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expiresWithin(tt.creds, 10*time.Minute); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
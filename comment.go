@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+var (
+	comment   bool
+	noComment bool
+)
+
+// wantComment reports whether to append a summary comment to the output. By
+// default it is only added when a person is reading the output in a
+// terminal, rather than a shell capturing it with $(cred), or a file or pipe
+// given with --out or --fifo.
+func wantComment() bool {
+	switch {
+	case comment:
+		return true
+	case noComment || outFile != "" || fifoPath != "":
+		return false
+	default:
+		return stdoutIsTerminal()
+	}
+}
+
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// summaryComment describes the resolved credentials in a shell comment, which
+// does not change what evaluating the output does.
+func summaryComment(res *resolution) string {
+	parts := []string{}
+	if res.creds.CanExpire {
+		parts = append(parts, fmt.Sprintf("expires %s", displayClock(res.creds.Expires)))
+	}
+	parts = append(parts, fmt.Sprintf("account %s", displayAccount(res.account)))
+	if parsed, err := arn.Parse(res.arn); err == nil {
+		parts = append(parts, parsed.Resource)
+	}
+	return fmt.Sprintf("# %s\n", strings.Join(parts, ", "))
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestWantComment(t *testing.T) {
+	// /dev/null is a character device, so it passes for a terminal.
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer null.Close()
+
+	tests := []struct {
+		name               string
+		comment, noComment bool
+		out, fifo          string
+		want               bool
+	}{
+		{name: "terminal", want: true},
+		{name: "--no-comment", noComment: true},
+		{name: "--out", out: "creds.sh"},
+		{name: "--fifo", fifo: "creds.fifo"},
+		{name: "--comment with --fifo", comment: true, fifo: "creds.fifo", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldStdout, oldComment, oldNoComment, oldOut, oldFIFO := os.Stdout, comment, noComment, outFile, fifoPath
+			t.Cleanup(func() {
+				os.Stdout, comment, noComment, outFile, fifoPath = oldStdout, oldComment, oldNoComment, oldOut, oldFIFO
+			})
+			os.Stdout, comment, noComment, outFile, fifoPath = null, tt.comment, tt.noComment, tt.out, tt.fifo
+
+			if got := wantComment(); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
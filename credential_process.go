@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/spf13/cobra"
+)
+
+// processCredentials is the document that a credential_process prints for
+// the AWS SDKs to read.
+type processCredentials struct {
+	Version         int    `json:"Version"`
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken,omitempty"`
+	Expiration      string `json:"Expiration,omitempty"`
+}
+
+// credentialProcess renders the credentials as a credential_process
+// document. There is nothing to render without credentials, as for
+// `cred clear`.
+func credentialProcess(exports []variable, unsets []string) string {
+	doc := processCredentials{Version: 1}
+	for _, v := range exports {
+		switch v.key {
+		case accessKeyID:
+			doc.AccessKeyID = v.Value
+		case secretAccessKey:
+			doc.SecretAccessKey = v.Value
+		case sessionToken:
+			doc.SessionToken = v.Value
+		case sessionExpiresAt:
+			doc.Expiration = v.Value
+		}
+	}
+
+	if doc.AccessKeyID == "" {
+		return ""
+	}
+
+	data, _ := json.Marshal(doc)
+	return string(data) + "\n"
+}
+
+var processProfileName string
+
+var setupProcessCmd = &cobra.Command{
+	Use:   "setup-process",
+	Short: "Print the ~/.aws/config snippet that uses cred as a credential_process",
+	Long:  wordwrap.WrapString("Print the ~/.aws/config snippet that uses cred as a credential_process.\n\nThe snippet defines a new profile whose credentials are resolved by running this cred binary for the profile given with --profile. Add it to your ~/.aws/config file to use cred with any tool that reads AWS profiles.", 80),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if profile == "" {
+			return fmt.Errorf("--profile is required")
+		}
+
+		newProfile := processProfileName
+		if newProfile == "" {
+			newProfile = "cred-" + profile
+		}
+		if newProfile == profile {
+			return fmt.Errorf("--name must differ from --profile, or the profile would run cred for itself forever")
+		}
+
+		bin, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("Failed to locate the cred binary: %w", err)
+		}
+		if bin, err = filepath.EvalSymlinks(bin); err != nil {
+			return fmt.Errorf("Failed to locate the cred binary: %w", err)
+		}
+		if strings.Contains(bin, " ") {
+			bin = fmt.Sprintf("%q", bin)
+		}
+
+		fmt.Printf("[profile %s]\ncredential_process = %s --profile %s --format credential-process\n", newProfile, bin, profile)
+		return nil
+	},
+}
+
+func init() {
+	setupProcessCmd.Flags().StringVar(&profile, "profile", "", "AWS profile that cred should resolve credentials for")
+	setupProcessCmd.Flags().StringVar(&processProfileName, "name", "", "Name of the new profile, defaults to cred-<profile>")
+
+	rootCmd.AddCommand(setupProcessCmd)
+}
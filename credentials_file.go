@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// credentialsFileOptions returns load options that restrict the SDK to the
+// standalone credentials file at path, after checking that the file exists
+// and contains the requested profile.
+func credentialsFileOptions(ctx context.Context, path, profile string) ([]func(*config.LoadOptions) error, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("Failed to read credentials file: %w", err)
+	}
+
+	if profile == "" {
+		profile = "default"
+	}
+
+	_, err := config.LoadSharedConfigProfile(ctx, profile, func(o *config.LoadSharedConfigOptions) {
+		o.CredentialsFiles = []string{path}
+		o.ConfigFiles = []string{}
+	})
+	var notExist config.SharedConfigProfileNotExistError
+	if errors.As(err, &notExist) {
+		return nil, fmt.Errorf("Profile %q is not present in credentials file %s%s", profile, path, didYouMean(profile))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read credentials file: %w", err)
+	}
+
+	return []func(*config.LoadOptions) error{
+		config.WithSharedCredentialsFiles([]string{path}),
+		config.WithSharedConfigFiles([]string{}),
+		config.WithSharedConfigProfile(profile),
+	}, nil
+}
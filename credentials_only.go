@@ -0,0 +1,27 @@
+package main
+
+import "slices"
+
+var onlyCredentials bool
+
+// contextVars describe the account and region the credentials are for,
+// rather than the credentials themselves.
+var contextVars = []string{accountID, accountAliasVar, defaultRegion, region}
+
+// withoutContextVars removes the account and region variables from exports
+// and unsets with --emit-only-credentials, for tools that manage those
+// themselves.
+func withoutContextVars(exports []variable, unsets []string) ([]variable, []string) {
+	if !onlyCredentials {
+		return exports, unsets
+	}
+
+	names := []string{}
+	for _, key := range contextVars {
+		names = append(names, name(key))
+	}
+
+	exports = slices.DeleteFunc(exports, func(v variable) bool { return slices.Contains(names, v.Name) })
+	unsets = slices.DeleteFunc(unsets, func(n string) bool { return slices.Contains(names, n) })
+	return exports, unsets
+}
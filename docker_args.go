@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/spf13/cobra"
+)
+
+// dockerArgs renders the standard AWS variables among exports as `docker run`
+// environment flags.
+func dockerArgs(exports []variable) string {
+	args := []string{}
+	for _, v := range exports {
+		if slices.Contains(allVars(), v.key) {
+			args = append(args, fmt.Sprintf("-e %s=%s", v.key, v.Value))
+		}
+	}
+	return strings.Join(args, " ") + "\n"
+}
+
+var dockerArgsCmd = &cobra.Command{
+	Use:   "docker-args",
+	Short: "Print docker run flags that pass AWS credentials to a container",
+	Long:  wordwrap.WrapString("Print docker run flags that pass AWS credentials to a container, e.g. docker run $(cred docker-args) amazon/aws-cli sts get-caller-identity.\n\nOnly the standard AWS variables are included, without any --prefix. The flags contain the secrets, so they end up in your shell history if you paste them into a command instead of substituting them.", 80),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res, err := resolve(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		// Flags printed to a terminal are likely to be copied into a command.
+		if stdoutIsTerminal() {
+			fmt.Fprintln(stderr, "Warning: these flags contain secrets; pasting them into a command saves them in your shell history")
+		}
+		fmt.Print(dockerArgs(res.exports))
+		return nil
+	},
+}
+
+func init() {
+	addCredentialFlags(dockerArgsCmd)
+	dockerArgsCmd.Flags().BoolVar(&printSummary, "summary", false, "Print a one-line summary of the resolved credentials to stderr, without secrets")
+	dockerArgsCmd.Flags().StringVar(&traceOut, "trace-out", "", "Write a JSON timing summary of resolving the credentials and each AWS call to this file, without secrets")
+
+	rootCmd.AddCommand(dockerArgsCmd)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+)
+
+var durationProbe bool
+
+// maxDurationProbes bounds the AssumeRole calls made to probe a role's
+// maximum session duration, enough for a binary search of 1h to 12h.
+const maxDurationProbes = 4
+
+// probedProvider returns the credentials obtained while probing the first
+// time it is asked, and defers to the provider after that.
+type probedProvider struct {
+	creds *aws.Credentials
+	aws.CredentialsProvider
+}
+
+func (p *probedProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	if p.creds != nil {
+		creds := *p.creds
+		p.creds = nil
+		return creds, nil
+	}
+	return p.CredentialsProvider.Retrieve(ctx)
+}
+
+// durationTooLong reports whether err is STS rejecting an AssumeRole call
+// because it asked for a longer session than the role allows.
+func durationTooLong(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "ValidationError" && strings.Contains(apiErr.ErrorMessage(), "DurationSeconds")
+}
+
+// probeDuration finds the longest session the role allows, in whole hours, by
+// assuming it with the providers that newProvider returns for each duration
+// it tries. It starts with the duration found last time, if any, and returns
+// the credentials of the longest session it obtained.
+func probeDuration(ctx context.Context, roleARN string, newProvider func(time.Duration) aws.CredentialsProvider) (time.Duration, *aws.Credentials, error) {
+	s, err := loadState()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	lo, hi := time.Hour, 12*time.Hour
+	var best *aws.Credentials
+	try := func(d time.Duration) error {
+		creds, err := newProvider(d).Retrieve(ctx)
+		switch {
+		case err == nil:
+			lo, best = d, &creds
+		case durationTooLong(err):
+			hi = d - time.Hour
+		default:
+			return err
+		}
+		return nil
+	}
+
+	probes := 0
+	if cached := time.Duration(s.RoleMaxDurations[roleARN]) * time.Second; cached > lo && cached <= hi {
+		if err := try(cached); err != nil {
+			return 0, nil, err
+		}
+		probes++
+		if best != nil {
+			return cached, best, nil
+		}
+	}
+
+	for ; lo < hi && probes < maxDurationProbes; probes++ {
+		mid := ((lo + hi + time.Hour) / 2).Truncate(time.Hour)
+		if err := try(mid); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	fmt.Fprintf(stderr, "Discovered a maximum session duration of %s for %s\n", strings.TrimSuffix(lo.String(), "0m0s"), roleARN)
+
+	if s.RoleMaxDurations[roleARN] != int32(lo.Seconds()) {
+		if s.RoleMaxDurations == nil {
+			s.RoleMaxDurations = map[string]int32{}
+		}
+		s.RoleMaxDurations[roleARN] = int32(lo.Seconds())
+		if err := s.save(); err != nil {
+			fmt.Fprintf(stderr, "Warning: %s\n", err)
+		}
+	}
+
+	return lo, best, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/spf13/cobra"
+)
+
+var clusterName string
+
+const (
+	// eksTokenPrefix marks a token as a presigned GetCallerIdentity URL for
+	// the EKS authenticator.
+	eksTokenPrefix = "k8s-aws-v1."
+
+	// The presigned URL is valid for 15 minutes. Like `aws eks get-token`,
+	// report an expiry a minute earlier so clients refresh it in time.
+	eksTokenLifetime = 14 * time.Minute
+)
+
+// execCredential is the document that a kubeconfig exec plugin prints.
+type execCredential struct {
+	Kind       string               `json:"kind"`
+	APIVersion string               `json:"apiVersion"`
+	Spec       struct{}             `json:"spec"`
+	Status     execCredentialStatus `json:"status"`
+}
+
+type execCredentialStatus struct {
+	ExpirationTimestamp time.Time `json:"expirationTimestamp"`
+	Token               string    `json:"token"`
+}
+
+var eksTokenCmd = &cobra.Command{
+	Use:   "eks-token",
+	Short: "Print an EKS authentication token as a Kubernetes ExecCredential",
+	Long:  wordwrap.WrapString("Print an EKS authentication token as a Kubernetes ExecCredential.\n\nThe token is a presigned STS GetCallerIdentity URL, the same as `aws eks get-token` produces, so cred can be used as a kubeconfig exec plugin.", 80),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		if clusterName == "" {
+			return fmt.Errorf("--cluster is required")
+		}
+
+		scope, err := readSessionScope(ctx)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig(ctx, scope)
+		if err != nil {
+			return err
+		}
+
+		client := sts.NewPresignClient(sts.NewFromConfig(cfg, func(o *sts.Options) {
+			if o.Region == "" {
+				o.Region = "us-east-1"
+			}
+		}))
+
+		expires := now.Now().Add(eksTokenLifetime)
+		req, err := client.PresignGetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}, func(o *sts.PresignOptions) {
+			o.ClientOptions = append(o.ClientOptions, func(o *sts.Options) {
+				o.APIOptions = append(o.APIOptions,
+					smithyhttp.SetHeaderValue("x-k8s-aws-id", clusterName),
+					smithyhttp.SetHeaderValue("X-Amz-Expires", "60"),
+				)
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to presign EKS token: %w", err)
+		}
+
+		token := eksTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(req.URL))
+
+		enc := json.NewEncoder(os.Stdout)
+		return enc.Encode(execCredential{
+			Kind:       "ExecCredential",
+			APIVersion: "client.authentication.k8s.io/v1beta1",
+			Status: execCredentialStatus{
+				ExpirationTimestamp: expires.UTC().Truncate(time.Second),
+				Token:               token,
+			},
+		})
+	},
+}
+
+func init() {
+	addCredentialFlags(eksTokenCmd)
+	eksTokenCmd.Flags().StringVar(&clusterName, "cluster", "", "Name of the EKS cluster")
+
+	rootCmd.AddCommand(eksTokenCmd)
+}
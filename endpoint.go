@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+var (
+	endpointURL        string
+	insecureSkipVerify bool
+	minTLSVersion      string
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// endpointOptions returns load options that send every AWS request to
+// --endpoint-url, e.g. LocalStack, instead of the real AWS endpoints, and
+// that configure TLS for every AWS request.
+func endpointOptions() ([]func(*config.LoadOptions) error, error) {
+	opts := []func(*config.LoadOptions) error{}
+
+	if endpointURL != "" {
+		u, err := url.Parse(endpointURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("Invalid --endpoint-url %q: must be an http or https URL", endpointURL)
+		}
+		opts = append(opts, config.WithBaseEndpoint(endpointURL))
+	} else if insecureSkipVerify {
+		return nil, fmt.Errorf("--insecure-skip-verify can only be used with --endpoint-url")
+	}
+
+	var minVersion uint16
+	if minTLSVersion != "" {
+		v, ok := tlsVersions[minTLSVersion]
+		if !ok {
+			return nil, fmt.Errorf("Invalid --min-tls-version %q: must be one of %s", minTLSVersion, strings.Join(slices.Sorted(maps.Keys(tlsVersions)), ", "))
+		}
+		minVersion = v
+	}
+
+	if insecureSkipVerify || minVersion != 0 {
+		client := awshttp.NewBuildableClient().WithTransportOptions(func(t *http.Transport) {
+			if t.TLSClientConfig == nil {
+				t.TLSClientConfig = &tls.Config{}
+			}
+			t.TLSClientConfig.InsecureSkipVerify = insecureSkipVerify
+			if minVersion != 0 {
+				t.TLSClientConfig.MinVersion = minVersion
+			}
+		})
+		opts = append(opts, config.WithHTTPClient(client))
+	}
+
+	return opts, nil
+}
+
+// validateEndpoint checks the endpoint and TLS flags, and warns once about
+// --insecure-skip-verify, however many configs are loaded with them.
+func validateEndpoint() error {
+	if _, err := endpointOptions(); err != nil {
+		return err
+	}
+
+	if insecureSkipVerify {
+		u, _ := url.Parse(endpointURL)
+		fmt.Fprintf(stderr, "Warning: not verifying the TLS certificate of %s; only use --insecure-skip-verify for testing\n", u.Host)
+	}
+
+	return nil
+}
+
+// clientConfig returns a config for clients that cred makes before the config
+// is loaded, with the region and with the HTTP client, endpoint and API
+// options that opts set.
+func clientConfig(region string, opts []func(*config.LoadOptions) error) (aws.Config, error) {
+	var lo config.LoadOptions
+	for _, opt := range opts {
+		if err := opt(&lo); err != nil {
+			return aws.Config{}, err
+		}
+	}
+
+	cfg := aws.Config{Region: region, HTTPClient: lo.HTTPClient, APIOptions: lo.APIOptions}
+	if lo.BaseEndpoint != "" {
+		cfg.BaseEndpoint = aws.String(lo.BaseEndpoint)
+	}
+	return cfg, nil
+}
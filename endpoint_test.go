@@ -0,0 +1,18 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestInsecureSkipVerifyWarning checks that the warning is printed once, not
+// once for every profile that is tried.
+func TestInsecureSkipVerifyWarning(t *testing.T) {
+	out, err := runCred(t, newFakeAWS(t), "--insecure-skip-verify", "--profile-fallback", "broken,other,test")
+	if err != nil {
+		t.Fatalf("unexpected error %v, stderr:\n%s", err, out)
+	}
+	if got := strings.Count(out, "Warning: not verifying the TLS certificate"); got != 1 {
+		t.Errorf("got %d warnings, want 1, stderr:\n%s", got, out)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/spf13/cobra"
+)
+
+// envSnapshot is the diagnostic document printed by `cred env-json`.
+type envSnapshot struct {
+	Version         string            `json:"version"`
+	Environment     map[string]string `json:"environment"`
+	AccountName     string            `json:"account_name,omitempty"`
+	ConfigFile      fileInfo          `json:"config_file"`
+	CredentialsFile fileInfo          `json:"credentials_file"`
+}
+
+type fileInfo struct {
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+}
+
+func statFile(path string) fileInfo {
+	_, err := os.Stat(path)
+	return fileInfo{Path: path, Exists: !errors.Is(err, fs.ErrNotExist)}
+}
+
+func configFilePath() string {
+	if awsDir != "" {
+		return filepath.Join(awsDir, "config")
+	}
+	if path := os.Getenv("AWS_CONFIG_FILE"); path != "" {
+		return path
+	}
+	return config.DefaultSharedConfigFilename()
+}
+
+// mask hides the value of secret environment variables. Access key IDs keep
+// their first four characters, which identify the kind of key.
+func mask(key, val string) string {
+	switch {
+	case val == "":
+		return val
+	case strings.HasSuffix(key, accessKeyID) && len(val) > 4:
+		return val[:4] + "...****"
+	case strings.Contains(key, "SECRET"), strings.Contains(key, "TOKEN"), strings.Contains(key, "PASSWORD"):
+		return "****"
+	default:
+		return val
+	}
+}
+
+var envJSONCmd = &cobra.Command{
+	Use:   "env-json",
+	Short: "Print a JSON snapshot of the AWS environment, with secrets masked",
+	Long:  wordwrap.WrapString("Print a JSON snapshot of the AWS environment, with secrets masked.\n\nThe snapshot includes AWS environment variables, the config and credentials files that AWS SDKs will read, and the version of cred. It is meant to be pasted into bug reports.", 80),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env := map[string]string{}
+		for _, entry := range os.Environ() {
+			key, val, _ := strings.Cut(entry, "=")
+			if strings.HasPrefix(key, "AWS_") || (prefix != "" && strings.HasPrefix(key, name("AWS_"))) {
+				env[key] = mask(key, val)
+			}
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(envSnapshot{
+			Version:         version,
+			Environment:     env,
+			AccountName:     accountName(os.Getenv(name(accountID))),
+			ConfigFile:      statFile(configFilePath()),
+			CredentialsFile: statFile(credentialsFilePath()),
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(envJSONCmd)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/spf13/cobra"
+)
+
+var (
+	inheritRegion  bool
+	keepVars       []string
+	awsVaultCompat bool
+)
+
+// awsVaultArgs splits the arguments of `cred exec` in aws-vault's form,
+// `profile -- command [args...]`, into the profile and the command.
+func awsVaultArgs(args []string) (string, []string, bool) {
+	if len(args) >= 3 && args[1] == "--" {
+		return args[0], args[2:], true
+	}
+	return "", args, false
+}
+
+// awsVaultVariables returns the variables that aws-vault sets in addition to
+// the credentials, which some scripts rely on.
+func awsVaultVariables(res *resolution) []variable {
+	vars := []variable{{Name: "AWS_VAULT", Value: cmp.Or(profile, "default"), key: "AWS_VAULT"}}
+	if res.creds.CanExpire {
+		vars = append(vars, variable{Name: "AWS_CREDENTIAL_EXPIRATION", Value: res.creds.Expires.UTC().Format(time.RFC3339), key: "AWS_CREDENTIAL_EXPIRATION"})
+	}
+	return vars
+}
+
+// strippedVars are the variables that `cred exec` removes from the parent's
+// environment: those cred manages, and those that select other credentials.
+// SDK settings, e.g. AWS_CA_BUNDLE or AWS_CONFIG_FILE, are passed through.
+func strippedVars() []string {
+	return append(allVars(), "AWS_PROFILE", "AWS_DEFAULT_PROFILE", "AWS_VAULT", "AWS_CREDENTIAL_EXPIRATION")
+}
+
+// childEnv builds the environment for a command run by `cred exec`: the
+// parent's environment without the stripped variables, plus the resolved
+// credentials. Variables named in keep are passed through from the parent
+// unchanged, taking precedence over cred's own values.
+func childEnv(parent []string, exports []variable, keep []string) []string {
+	env := []string{}
+	kept := map[string]bool{}
+	stripped := strippedVars()
+
+	for _, entry := range parent {
+		key, _, _ := strings.Cut(entry, "=")
+		switch {
+		case slices.Contains(keep, key):
+			env = append(env, entry)
+			kept[key] = true
+		case !slices.Contains(stripped, key):
+			env = append(env, entry)
+		}
+	}
+
+	for _, v := range exports {
+		if !kept[v.key] {
+			env = append(env, fmt.Sprintf("%s=%s", v.key, v.Value))
+		}
+	}
+
+	return env
+}
+
+var execCmd = &cobra.Command{
+	Use:   "exec [flags] -- command [args...]",
+	Short: "Run a command with AWS credentials set as environment variables",
+	Long:  wordwrap.WrapString("Run a command with AWS credentials set as environment variables.\n\nThe command gets a clean AWS environment: the variables that cred manages, and AWS_PROFILE, are removed from your current environment, and only the variables that cred resolves are set. Other AWS_ variables, such as AWS_CA_BUNDLE or AWS_CONFIG_FILE, are passed through. Use --keep to pass specific variables through unchanged.", 80),
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		if awsVaultCompat {
+			if p, command, ok := awsVaultArgs(args); ok {
+				if cmd.Flags().Changed("profile") {
+					return fmt.Errorf("Give the profile either with --profile or before --, not both")
+				}
+				profile, args = p, command
+			}
+		}
+
+		keep := slices.Clone(keepVars)
+		if inheritRegion {
+			keep = append(keep, region, defaultRegion)
+		}
+
+		parent := os.Environ()
+
+		res, err := resolve(ctx)
+		if err != nil {
+			return err
+		}
+
+		exports := res.exports
+		if awsVaultCompat {
+			exports = append(exports, awsVaultVariables(res)...)
+		}
+
+		child := exec.Command(args[0], args[1:]...)
+		child.Env = childEnv(parent, exports, keep)
+		child.Stdin = os.Stdin
+		child.Stdout = os.Stdout
+		child.Stderr = os.Stderr
+
+		// The child shares the terminal, so it receives interrupts directly.
+		// Keep cred alive until the child decides how to handle them.
+		signal.Ignore(os.Interrupt)
+
+		err = child.Run()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		return err
+	},
+}
+
+func init() {
+	// Flags after the command name belong to the command, not to cred.
+	execCmd.Flags().SetInterspersed(false)
+
+	addCredentialFlags(execCmd)
+	addGuardFlags(execCmd)
+	execCmd.Flags().BoolVar(&printSummary, "summary", false, "Print a one-line summary of the resolved credentials to stderr, without secrets")
+	execCmd.Flags().StringVar(&traceOut, "trace-out", "", "Write a JSON timing summary of resolving the credentials and each AWS call to this file, without secrets")
+	execCmd.Flags().BoolVar(&legacyToken, "legacy-token", false, "Also set the session token as AWS_SECURITY_TOKEN for legacy SDKs")
+	execCmd.Flags().BoolVar(&inheritRegion, "inherit-region", false, "Pass AWS_REGION and AWS_DEFAULT_REGION through from the current environment")
+	execCmd.Flags().BoolVar(&awsVaultCompat, "aws-vault-compat", false, "Accept aws-vault's profile -- command arguments, and set AWS_VAULT and AWS_CREDENTIAL_EXPIRATION like aws-vault does")
+	execCmd.Flags().StringArrayVar(&keepVars, "keep", nil, "Pass this variable through from the current environment, can be repeated")
+
+	rootCmd.AddCommand(execCmd)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestChildEnv(t *testing.T) {
+	parent := []string{
+		"PATH=/usr/bin",
+		"AWS_ACCESS_KEY_ID=AKIDSTALE",
+		"AWS_SECRET_ACCESS_KEY=stale-secret",
+		"AWS_SESSION_TOKEN=stale-token",
+		"AWS_PROFILE=other",
+		"AWS_REGION=eu-west-1",
+		"AWS_VAULT=other",
+		"AWS_CA_BUNDLE=/etc/ssl/proxy.pem",
+		"AWS_CONFIG_FILE=/etc/aws/config",
+		"AWS_SHARED_CREDENTIALS_FILE=/etc/aws/credentials",
+		"AWS_STS_REGIONAL_ENDPOINTS=regional",
+	}
+	exports := []variable{
+		{Name: "DEV_AWS_ACCESS_KEY_ID", Value: "ASIANEW", key: accessKeyID},
+		{Name: "DEV_AWS_REGION", Value: "us-east-1", key: region},
+	}
+
+	tests := []struct {
+		name string
+		keep []string
+		want []string
+	}{
+		{
+			name: "default",
+			want: []string{
+				"PATH=/usr/bin",
+				"AWS_CA_BUNDLE=/etc/ssl/proxy.pem",
+				"AWS_CONFIG_FILE=/etc/aws/config",
+				"AWS_SHARED_CREDENTIALS_FILE=/etc/aws/credentials",
+				"AWS_STS_REGIONAL_ENDPOINTS=regional",
+				"AWS_ACCESS_KEY_ID=ASIANEW",
+				"AWS_REGION=us-east-1",
+			},
+		},
+		{
+			name: "keep",
+			keep: []string{region, "AWS_PROFILE"},
+			want: []string{
+				"PATH=/usr/bin",
+				"AWS_PROFILE=other",
+				"AWS_REGION=eu-west-1",
+				"AWS_CA_BUNDLE=/etc/ssl/proxy.pem",
+				"AWS_CONFIG_FILE=/etc/aws/config",
+				"AWS_SHARED_CREDENTIALS_FILE=/etc/aws/credentials",
+				"AWS_STS_REGIONAL_ENDPOINTS=regional",
+				"AWS_ACCESS_KEY_ID=ASIANEW",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := childEnv(parent, exports, tt.keep); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
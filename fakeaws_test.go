@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// The secrets of the fake profile and of the roles the fake assumes, which
+// must never appear on stderr.
+const (
+	fakeSecret      = "fake-secret-access-key"
+	fakeRoleSecret  = "fake-role-secret-access-key"
+	fakeRoleToken   = "fake-role-session-token"
+	fakeAccount     = "123456789012"
+	fakeCallerARN   = "arn:aws:iam::123456789012:user/alice"
+	fakeAccessKeyID = "AKIDFAKE"
+)
+
+// fakeResponses are the successful responses to the STS and IAM actions that
+// cred calls.
+var fakeResponses = map[string]string{
+	"GetCallerIdentity":  `<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><GetCallerIdentityResult><Arn>` + fakeCallerARN + `</Arn><UserId>AIDAFAKE</UserId><Account>` + fakeAccount + `</Account></GetCallerIdentityResult><ResponseMetadata><RequestId>1</RequestId></ResponseMetadata></GetCallerIdentityResponse>`,
+	"AssumeRole":         `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><AssumeRoleResult><Credentials><AccessKeyId>ASIAFAKE</AccessKeyId><SecretAccessKey>` + fakeRoleSecret + `</SecretAccessKey><SessionToken>` + fakeRoleToken + `</SessionToken><Expiration>2099-01-01T00:00:00Z</Expiration></Credentials><AssumedRoleUser><Arn>arn:aws:sts::123456789012:assumed-role/Hop/me</Arn><AssumedRoleId>AROAFAKE:me</AssumedRoleId></AssumedRoleUser></AssumeRoleResult><ResponseMetadata><RequestId>1</RequestId></ResponseMetadata></AssumeRoleResponse>`,
+	"GetRole":            `<GetRoleResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/"><GetRoleResult><Role><Path>/</Path><RoleName>Admin</RoleName><RoleId>AROAFAKE</RoleId><Arn>arn:aws:iam::123456789012:role/Admin</Arn><CreateDate>2020-01-01T00:00:00Z</CreateDate><MaxSessionDuration>43200</MaxSessionDuration></Role></GetRoleResult><ResponseMetadata><RequestId>1</RequestId></ResponseMetadata></GetRoleResponse>`,
+	"GetRoleCredentials": `{"roleCredentials": {"accessKeyId": "ASIASSO", "secretAccessKey": "` + fakeRoleSecret + `", "sessionToken": "` + fakeRoleToken + `", "expiration": 4070908800000}}`,
+	"ListAccountAliases": `<ListAccountAliasesResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/"><ListAccountAliasesResult><IsTruncated>false</IsTruncated><AccountAliases><member>fake-alias</member></AccountAliases></ListAccountAliasesResult><ResponseMetadata><RequestId>1</RequestId></ResponseMetadata></ListAccountAliasesResponse>`,
+}
+
+// fakeError is the body of an STS or IAM error response.
+func fakeError(code, message string) string {
+	return fmt.Sprintf(`<ErrorResponse><Error><Type>Sender</Type><Code>%s</Code><Message>%s</Message></Error><RequestId>1</RequestId></ErrorResponse>`, code, message)
+}
+
+// fakeAWS is an httptest server that answers STS and IAM Query API calls, for
+// tests that point cred at it as its endpoint.
+type fakeAWS struct {
+	*httptest.Server
+
+	// home is the home and config directory that runCred runs cred with.
+	home string
+
+	mu    sync.Mutex
+	calls []string
+
+	// handle overrides the status and body of the response to an action.
+	handle map[string]func(r *http.Request) (int, string)
+}
+
+func newFakeAWS(t *testing.T) *fakeAWS {
+	t.Helper()
+	f := &fakeAWS{home: t.TempDir(), handle: map[string]func(*http.Request) (int, string){}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeAWS) serve(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	action, contentType := r.Form.Get("Action"), "text/xml"
+	if r.URL.Path == "/federation/credentials" {
+		action, contentType = "GetRoleCredentials", "application/json"
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, action)
+	h := f.handle[action]
+	f.mu.Unlock()
+
+	status, body := http.StatusOK, fakeResponses[action]
+	if h != nil {
+		status, body = h(r)
+	} else if body == "" {
+		status, body = http.StatusBadRequest, fakeError("InvalidAction", "Unknown action "+action)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	io.WriteString(w, body)
+}
+
+// called reports how many times action was called.
+func (f *fakeAWS) called(action string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := 0
+	for _, c := range f.calls {
+		if c == action {
+			n++
+		}
+	}
+	return n
+}
+
+// config returns a config with static credentials that sends every request
+// to the fake, without retries.
+func (f *fakeAWS) config() aws.Config {
+	return aws.Config{
+		Region:       "us-east-1",
+		Credentials:  credentials.NewStaticCredentialsProvider(fakeAccessKeyID, fakeSecret, ""),
+		BaseEndpoint: aws.String(f.URL),
+		Retryer:      func() aws.Retryer { return aws.NopRetryer{} },
+	}
+}
+
+// fakeConfig is the shared config file that runCred uses. Profile "test" has
+// static credentials, "admin" reaches a role through "jump", and "corp" is an
+// SSO session.
+const fakeConfig = `[profile test]
+region = us-east-1
+
+[profile jump]
+role_arn = arn:aws:iam::123456789012:role/Jump
+source_profile = test
+
+[profile admin]
+role_arn = arn:aws:iam::123456789012:role/Admin
+source_profile = jump
+
+[sso-session corp]
+sso_start_url = https://corp.awsapps.com/start
+sso_region = us-east-1
+`
+
+// runCred runs cred with args against the fake, using the profile "test"
+// unless args select another one or use --profile-fallback, and returns what
+// it wrote to stderr. Flags are reset to their defaults first, and stdout is
+// discarded.
+func runCred(t *testing.T, f *fakeAWS, args ...string) (string, error) {
+	t.Helper()
+
+	dir := f.home
+	writeTestFile(t, filepath.Join(dir, "config"), fakeConfig)
+	writeTestFile(t, filepath.Join(dir, "credentials"), fmt.Sprintf("[test]\naws_access_key_id = %s\naws_secret_access_key = %s\n", fakeAccessKeyID, fakeSecret))
+
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	for _, key := range append(allVars(), "AWS_PROFILE", "AWS_DEFAULT_PROFILE") {
+		t.Setenv(key, "")
+	}
+
+	for _, cmd := range append(rootCmd.Commands(), rootCmd) {
+		resetFlags(cmd)
+	}
+
+	var buf bytes.Buffer
+	oldStderr, oldStdout := stderr, os.Stdout
+	stderr = &redactor{w: &buf}
+	t.Cleanup(func() { stderr, os.Stdout = oldStderr, oldStdout })
+
+	out, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	os.Stdout = out
+
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(stderr)
+	base := []string{"--endpoint-url", f.URL}
+	if !slices.Contains(args, "--profile-fallback") {
+		base = append(base, "--profile", "test")
+	}
+	rootCmd.SetArgs(append(base, args...))
+	err = rootCmd.ExecuteContext(context.Background())
+	return buf.String(), err
+}
+
+func writeTestFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var (
+	fifoPath    string
+	fifoTimeout time.Duration
+)
+
+// writeFIFO writes output to the named pipe at path, creating it if it does
+// not exist, once another process opens it for reading. A pipe that cred
+// created is removed again afterwards.
+func writeFIFO(path string, output string, wait time.Duration) error {
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if err := mkfifo(path); err != nil {
+			return fmt.Errorf("Failed to create named pipe: %w", err)
+		}
+		defer os.Remove(path)
+
+		// Waiting for a reader can take a while, so remove the pipe if cred is
+		// interrupted, too.
+		interrupted := make(chan os.Signal, 1)
+		signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(interrupted)
+		go func() {
+			if _, ok := <-interrupted; ok {
+				os.Remove(path)
+				os.Exit(130)
+			}
+		}()
+	case err != nil:
+		return fmt.Errorf("Failed to write named pipe: %w", err)
+	case info.Mode()&fs.ModeNamedPipe == 0:
+		return fmt.Errorf("Failed to write named pipe: %s exists and is not a named pipe", path)
+	}
+
+	f, err := openFIFO(path, time.Now().Add(wait))
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(output); err != nil {
+		f.Close()
+		return fmt.Errorf("Failed to write named pipe: %w", err)
+	}
+	return f.Close()
+}
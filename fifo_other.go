@@ -0,0 +1,19 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+var errFIFOUnsupported = errors.New("--fifo is not supported on this platform")
+
+func mkfifo(path string) error {
+	return errFIFOUnsupported
+}
+
+func openFIFO(path string, deadline time.Time) (*os.File, error) {
+	return nil, errFIFOUnsupported
+}
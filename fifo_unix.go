@@ -0,0 +1,34 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+func mkfifo(path string) error {
+	return syscall.Mkfifo(path, 0o600)
+}
+
+// openFIFO opens the pipe for writing once a reader has opened it. Opening a
+// pipe blocks until then, so it is opened without blocking and retried until
+// the deadline instead.
+func openFIFO(path string, deadline time.Time) (*os.File, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, syscall.ENXIO) {
+			return nil, fmt.Errorf("Failed to open named pipe: %w", err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("Timed out waiting for a reader to open %s", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+)
+
+var fishUniversal bool
+
+// fish renders the variables as fish shell commands. By default they are
+// global to the current session; with --fish-universal they are universal
+// variables, which fish shares with every session and saves to disk.
+func fish(exports []variable, unsets []string) string {
+	setFlags, eraseFlags := "-gx", "-e"
+	if fishUniversal {
+		fmt.Fprintln(stderr, "Warning: --fish-universal saves the credentials in fish's universal variable storage on disk")
+		setFlags, eraseFlags = "-Ux", "-eU"
+	}
+
+	lines := ""
+	for _, key := range unsets {
+		lines += fmt.Sprintf("set %s %s;\n", eraseFlags, key)
+	}
+	for _, v := range exports {
+		lines += fmt.Sprintf("set %s %s %s;\n", setFlags, v.Name, v.Value)
+	}
+	return lines
+}
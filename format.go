@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	format     string
+	outFile    string
+	outputSort bool
+	separator  string
+
+	alwaysWrite bool
+)
+
+// outputFormat is a built-in rendering of the variables that cred sets and
+// unsets, selected with --format.
+type outputFormat struct {
+	render func(exports []variable, unsets []string) string
+
+	// clearable formats can unset variables, so `cred clear` can default to
+	// them. The others ignore unsets, and clearing in them prints nothing.
+	clearable bool
+}
+
+var formats = map[string]outputFormat{
+	"sh":                 {render: script, clearable: true},
+	"fish":               {render: fish, clearable: true},
+	"aws-powershell":     {render: awsPowerShell, clearable: true},
+	"kv":                 {render: keyValues},
+	"properties":         {render: properties},
+	"credential-process": {render: credentialProcess},
+	"http":               {render: httpResponse},
+}
+
+func formatNames() []string {
+	names := []string{}
+	for name := range formats {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func addFormatFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&format, "format", "sh", fmt.Sprintf("Output format, one of: %s", strings.Join(formatNames(), ", ")))
+	cmd.Flags().StringVar(&outFile, "out", "", "Write the output to this file instead of stdout")
+	cmd.Flags().BoolVar(&alwaysWrite, "always-write", false, "Rewrite output files even if they already contain the same output")
+	cmd.Flags().StringVar(&fifoPath, "fifo", "", "Write the output to this named pipe, creating it if needed, once a reader opens it")
+	cmd.Flags().DurationVar(&fifoTimeout, "fifo-timeout", 30*time.Second, "Give up waiting for a reader to open the --fifo pipe after this long")
+	cmd.MarkFlagsMutuallyExclusive("out", "fifo")
+	cmd.Flags().BoolVar(&fishUniversal, "fish-universal", false, "With --format fish, use universal variables that persist across sessions")
+	cmd.Flags().StringVar(&separator, "separator", "=", "With --format kv, the separator between each name and value")
+	cmd.Flags().BoolVar(&outputSort, "output-sort", false, "Print variables sorted by name instead of in the default order")
+	cmd.MarkFlagsMutuallyExclusive("format", "format-template")
+}
+
+// sortOutput sorts the variables by name in place if --output-sort was given.
+func sortOutput(exports []variable, unsets []string) {
+	if !outputSort {
+		return
+	}
+	slices.SortFunc(exports, func(a, b variable) int { return strings.Compare(a.Name, b.Name) })
+	slices.Sort(unsets)
+}
+
+func validateFormat(cmd *cobra.Command, args []string) error {
+	if _, ok := formats[format]; !ok {
+		return fmt.Errorf("Invalid format %q: must be one of %s", format, strings.Join(formatNames(), ", "))
+	}
+	if separator == "" {
+		return fmt.Errorf("Invalid --separator: must not be empty")
+	}
+	return nil
+}
+
+// checkFishUniversal fails if --fish-universal was given for a format other
+// than fish, once the format is known.
+func checkFishUniversal() error {
+	if fishUniversal && format != "fish" {
+		return fmt.Errorf("--fish-universal can only be used with --format fish")
+	}
+	return nil
+}
+
+// emit writes output to the --fifo named pipe, the --out file, or to stdout.
+func emit(output string) error {
+	if fifoPath != "" {
+		return writeFIFO(fifoPath, output, fifoTimeout)
+	}
+
+	if outFile == "" {
+		fmt.Print(output)
+		return nil
+	}
+
+	if err := writeFileIfChanged(outFile, []byte(output)); err != nil {
+		return fmt.Errorf("Failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// rememberFormat records f as the last format used to export credentials, so
+// that `cred clear` can default to it. Only formats that can unset variables
+// are recorded, and only when the output went to stdout, since output written
+// to a file or pipe was not evaluated by the shell. Failing to record it is
+// not worth failing the export over, so errors are only reported.
+func rememberFormat(f string) {
+	if !formats[f].clearable || outFile != "" || fifoPath != "" {
+		return
+	}
+
+	s, err := loadState()
+	if err == nil && s.LastFormat != f {
+		s.LastFormat = f
+		err = s.save()
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: %s\n", err)
+	}
+}
+
+// lastFormat returns the format that credentials were last exported in.
+func lastFormat() string {
+	s, err := loadState()
+	if err != nil {
+		return format
+	}
+	if f, ok := formats[s.LastFormat]; !ok || !f.clearable {
+		return format
+	}
+	return s.LastFormat
+}
+
+// javaProperties maps cred's variables to the system properties read by the
+// AWS SDK for Java.
+var javaProperties = map[string]string{
+	accessKeyID:     "aws.accessKeyId",
+	secretAccessKey: "aws.secretAccessKey",
+	sessionToken:    "aws.sessionToken",
+	region:          "aws.region",
+}
+
+// properties renders the variables as Java properties file lines. Properties
+// files have no way to unset a value, so unsets are ignored, as is anything
+// the AWS SDK for Java does not read.
+func properties(exports []variable, unsets []string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+	lines := ""
+	for _, v := range exports {
+		if key, ok := javaProperties[v.key]; ok {
+			lines += fmt.Sprintf("%s=%s\n", key, escaper.Replace(v.Value))
+		}
+	}
+	return lines
+}
+
+// keyValues renders the variables as one name, separator and value per line,
+// for config files that are not quite dotenv. Unsets are ignored.
+func keyValues(exports []variable, unsets []string) string {
+	lines := ""
+	for _, v := range exports {
+		lines += v.Name + separator + v.Value + "\n"
+	}
+	return lines
+}
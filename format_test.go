@@ -0,0 +1,65 @@
+package main
+
+import "testing"
+
+func TestRememberFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		out      string
+		fifo     string
+		wantLast string
+	}{
+		{name: "sh", format: "sh", wantLast: "sh"},
+		{name: "aws-powershell", format: "aws-powershell", wantLast: "aws-powershell"},
+		{name: "properties", format: "properties", wantLast: "fish"},
+		{name: "kv", format: "kv", wantLast: "fish"},
+		{name: "credential-process", format: "credential-process", wantLast: "fish"},
+		{name: "sh to a file", format: "sh", out: "creds.sh", wantLast: "fish"},
+		{name: "sh to a pipe", format: "sh", fifo: "creds.fifo", wantLast: "fish"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+			oldFormat, oldOut, oldFIFO := format, outFile, fifoPath
+			t.Cleanup(func() { format, outFile, fifoPath = oldFormat, oldOut, oldFIFO })
+			format = "sh"
+
+			rememberFormat("fish")
+
+			outFile, fifoPath = tt.out, tt.fifo
+			rememberFormat(tt.format)
+
+			if got := lastFormat(); got != tt.wantLast {
+				t.Errorf("got %s, want %s", got, tt.wantLast)
+			}
+		})
+	}
+}
+
+// TestLastFormatUnclearable checks that a format recorded by an earlier
+// version of cred that cannot unset variables is not used by `cred clear`.
+func TestLastFormatUnclearable(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	s, err := loadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.LastFormat = "properties"
+	if err := s.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	oldFormat := format
+	t.Cleanup(func() { format = oldFormat })
+	format = "sh"
+
+	if got := lastFormat(); got != "sh" {
+		t.Errorf("got %s, want sh", got)
+	}
+}
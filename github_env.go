@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/spf13/cobra"
+)
+
+var githubEnvFile string
+
+// githubEnv renders the variables in the format of a GitHub Actions
+// environment file. Values containing newlines use the multiline syntax with
+// a random delimiter, so that no value can end the block early.
+func githubEnv(exports []variable) (string, error) {
+	lines := ""
+	for _, v := range exports {
+		if !strings.Contains(v.Value, "\n") {
+			lines += fmt.Sprintf("%s=%s\n", v.Name, v.Value)
+			continue
+		}
+
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		delimiter := "ghadelimiter_" + hex.EncodeToString(b)
+		lines += fmt.Sprintf("%s<<%s\n%s\n%s\n", v.Name, delimiter, v.Value, delimiter)
+	}
+	return lines, nil
+}
+
+var githubEnvCmd = &cobra.Command{
+	Use:   "github-env",
+	Short: "Export AWS credentials to later steps of a GitHub Actions job",
+	Long:  wordwrap.WrapString("Export AWS credentials to later steps of a GitHub Actions job.\n\nThe credentials are appended to the file named by $GITHUB_ENV, and the secret values are masked in the job's logs. GitHub environment files cannot unset variables, so variables that cred cannot resolve are left alone.", 80),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		path := githubEnvFile
+		if path == "" {
+			path = os.Getenv("GITHUB_ENV")
+		}
+		if path == "" {
+			return fmt.Errorf("GITHUB_ENV is not set; run this in GitHub Actions or pass --out")
+		}
+
+		res, err := resolve(ctx)
+		if err != nil {
+			return err
+		}
+
+		lines, err := githubEnv(res.exports)
+		if err != nil {
+			return err
+		}
+
+		// Workflow commands on stdout mask the secrets in the rest of the log.
+		for _, secret := range []string{res.creds.SecretAccessKey, res.creds.SessionToken} {
+			if secret != "" {
+				fmt.Printf("::add-mask::%s\n", secret)
+			}
+		}
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return fmt.Errorf("Failed to open GitHub environment file: %w", err)
+		}
+
+		if _, err := f.WriteString(lines); err != nil {
+			f.Close()
+			return fmt.Errorf("Failed to write GitHub environment file: %w", err)
+		}
+
+		return f.Close()
+	},
+}
+
+func init() {
+	addCredentialFlags(githubEnvCmd)
+	githubEnvCmd.Flags().BoolVar(&printSummary, "summary", false, "Print a one-line summary of the resolved credentials to stderr, without secrets")
+	githubEnvCmd.Flags().StringVar(&traceOut, "trace-out", "", "Write a JSON timing summary of resolving the credentials and each AWS call to this file, without secrets")
+	githubEnvCmd.Flags().StringVar(&githubEnvFile, "out", "", "Append to this file instead of the one named by $GITHUB_ENV")
+
+	rootCmd.AddCommand(githubEnvCmd)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+)
+
+var (
+	expectRegion     string
+	requireTemporary bool
+)
+
+// addGuardFlags registers the flags that make cred refuse to hand out
+// credentials that do not meet the user's expectations.
+func addGuardFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&expectRegion, "expect-region", "", "Fail unless the resolved region is this one")
+	cmd.Flags().BoolVar(&requireTemporary, "require-temporary", false, "Fail if the resolved credentials are long-lived")
+	cmd.Flags().StringSliceVar(&validateScope, "validate-scope", nil, fmt.Sprintf("Fail unless the credentials can perform these comma-separated actions, from: %s", strings.Join(scopeProbeNames(), ", ")))
+	cmd.Flags().BoolVar(&requireMFA, "require-mfa", false, "Fail unless the credentials are obtained by assuming a role with MFA")
+	cmd.Flags().BoolVar(&checkClock, "check-clock", false, "Fail if the local clock differs from AWS's by more than --clock-skew-threshold")
+	cmd.Flags().DurationVar(&clockSkewThreshold, "clock-skew-threshold", time.Minute, "How far the local clock may be from AWS's with --check-clock")
+	cmd.Flags().BoolVar(&clockSkewWarnOnly, "clock-skew-warn-only", false, "With --check-clock, only warn about a skewed clock")
+}
+
+// checkRegion fails if --expect-region was given and cfg resolved a different
+// region.
+func checkRegion(cfg aws.Config) error {
+	if expectRegion == "" || cfg.Region == expectRegion {
+		return nil
+	}
+
+	if cfg.Region == "" {
+		return fmt.Errorf("Expected region %s, but no region is configured", expectRegion)
+	}
+
+	return fmt.Errorf("Expected region %s, but the resolved region is %s", expectRegion, cfg.Region)
+}
+
+// checkTemporary fails if --require-temporary was given and creds have no
+// session token.
+func checkTemporary(creds aws.Credentials) error {
+	if requireTemporary && creds.SessionToken == "" {
+		return fmt.Errorf("The resolved credentials are long-lived and --require-temporary is set; assume a role instead")
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// containerCredentials is the document served by the container credentials
+// endpoints that AWS SDKs read from AWS_CONTAINER_CREDENTIALS_FULL_URI.
+type containerCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token,omitempty"`
+	Expiration      string `json:"Expiration,omitempty"`
+}
+
+// httpResponse renders the credentials as an HTTP/1.1 response with a
+// container credentials body, and an Expires header when the credentials
+// expire, so that HTTP caches do not keep them any longer. There is nothing
+// to render without credentials, as for `cred clear`.
+func httpResponse(exports []variable, unsets []string) string {
+	doc := containerCredentials{}
+	for _, v := range exports {
+		switch v.key {
+		case accessKeyID:
+			doc.AccessKeyID = v.Value
+		case secretAccessKey:
+			doc.SecretAccessKey = v.Value
+		case sessionToken:
+			doc.Token = v.Value
+		case sessionExpiresAt:
+			doc.Expiration = v.Value
+		}
+	}
+
+	if doc.AccessKeyID == "" {
+		return ""
+	}
+
+	body, _ := json.Marshal(doc)
+	body = append(body, '\n')
+
+	header := "HTTP/1.1 200 OK\r\n"
+	header += "Content-Type: application/json\r\n"
+	header += fmt.Sprintf("Content-Length: %d\r\n", len(body))
+	if expires, err := time.Parse(time.RFC3339, doc.Expiration); err == nil {
+		header += fmt.Sprintf("Expires: %s\r\n", expires.UTC().Format(http.TimeFormat))
+	}
+	return header + "\r\n" + string(body)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+	"golang.org/x/sync/errgroup"
+)
+
+// lookupIdentity validates the credentials with GetCallerIdentity and, when
+// withAlias is set, looks up the account alias concurrently.
+func lookupIdentity(ctx context.Context, cfg aws.Config, withAlias bool) (*sts.GetCallerIdentityOutput, string, error) {
+	var (
+		data  *sts.GetCallerIdentityOutput
+		alias string
+	)
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		var err error
+		data, err = getCallerIdentity(ctx, cfg)
+		return err
+	})
+
+	if withAlias {
+		g.Go(func() error {
+			var err error
+			alias, err = getAccountAlias(ctx, cfg)
+			return err
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, "", err
+	}
+
+	return data, alias, nil
+}
+
+// getAccountAlias returns the account's alias, or an empty string if it has
+// none or the credentials are not allowed to list it.
+func getAccountAlias(ctx context.Context, cfg aws.Config) (string, error) {
+	client := iam.NewFromConfig(cfg, iamRegion)
+
+	data, err := client.ListAccountAliases(ctx, &iam.ListAccountAliasesInput{})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "AccessDenied" {
+			return "", nil
+		}
+		return "", fmt.Errorf("Failed to look up account alias: %w", err)
+	}
+
+	if len(data.AccountAliases) == 0 {
+		return "", nil
+	}
+
+	return data.AccountAliases[0], nil
+}
+
+// iamRegion sets a region on IAM clients if none is configured. IAM is a
+// global service, so any region will do.
+func iamRegion(o *iam.Options) {
+	if o.Region == "" {
+		o.Region = "us-east-1"
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLookupIdentity(t *testing.T) {
+	tests := []struct {
+		name      string
+		withAlias bool
+		aliases   func(r *http.Request) (int, string)
+		wantAlias string
+		wantErr   bool
+	}{
+		{name: "alias", withAlias: true, wantAlias: "fake-alias"},
+		{name: "no alias wanted", withAlias: false},
+		{
+			name:      "access denied",
+			withAlias: true,
+			aliases: func(r *http.Request) (int, string) {
+				return http.StatusForbidden, fakeError("AccessDenied", "Not allowed to list aliases")
+			},
+		},
+		{
+			name:      "other error",
+			withAlias: true,
+			aliases: func(r *http.Request) (int, string) {
+				return http.StatusForbidden, fakeError("InvalidClientTokenId", "The security token is invalid")
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeAWS(t)
+
+			// GetCallerIdentity only answers once ListAccountAliases has been
+			// called, so the lookup only succeeds if both calls are made at
+			// the same time.
+			aliasCalled := make(chan struct{})
+			var once sync.Once
+			f.handle["ListAccountAliases"] = func(r *http.Request) (int, string) {
+				once.Do(func() { close(aliasCalled) })
+				if tt.aliases != nil {
+					return tt.aliases(r)
+				}
+				return http.StatusOK, fakeResponses["ListAccountAliases"]
+			}
+			f.handle["GetCallerIdentity"] = func(r *http.Request) (int, string) {
+				if tt.withAlias {
+					select {
+					case <-aliasCalled:
+					case <-time.After(5 * time.Second):
+						return http.StatusInternalServerError, fakeError("InternalFailure", "ListAccountAliases was not called concurrently")
+					}
+				}
+				return http.StatusOK, fakeResponses["GetCallerIdentity"]
+			}
+
+			data, alias, err := lookupIdentity(context.Background(), f.config(), tt.withAlias)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := *data.Arn; got != fakeCallerARN {
+				t.Errorf("got ARN %q, want %q", got, fakeCallerARN)
+			}
+			if alias != tt.wantAlias {
+				t.Errorf("got alias %q, want %q", alias, tt.wantAlias)
+			}
+			if got := f.called("GetCallerIdentity"); got != 1 {
+				t.Errorf("GetCallerIdentity was called %d times, want 1", got)
+			}
+			want := 0
+			if tt.withAlias {
+				want = 1
+			}
+			if got := f.called("ListAccountAliases"); got != want {
+				t.Errorf("ListAccountAliases was called %d times, want %d", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var sectionHeader = regexp.MustCompile(`^\s*\[\s*([^\]]*?)\s*\]`)
+
+// sectionBounds returns the line range [start, end) of the named section in
+// lines, including its header, or -1, -1 if the section is not present.
+func sectionBounds(lines []string, section string) (int, int) {
+	start := -1
+	for i, line := range lines {
+		match := sectionHeader.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if start >= 0 {
+			return start, i
+		}
+		if match[1] == section {
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		return start, len(lines)
+	}
+	return -1, -1
+}
+
+func splitLines(contents string) []string {
+	contents = strings.TrimRight(contents, "\n")
+	if contents == "" {
+		return nil
+	}
+	return strings.Split(contents, "\n")
+}
+
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// hasSection reports whether the INI contents contain the named section.
+func hasSection(contents, section string) bool {
+	start, _ := sectionBounds(splitLines(contents), section)
+	return start >= 0
+}
+
+// setSection returns the INI contents with the named section replaced by one
+// containing exactly values, in order. The section is appended if it does not
+// exist yet. Everything else in the file is left untouched.
+func setSection(contents, section string, values []variable) string {
+	body := []string{fmt.Sprintf("[%s]", section)}
+	for _, v := range values {
+		body = append(body, fmt.Sprintf("%s = %s", v.Name, v.Value))
+	}
+
+	lines := splitLines(contents)
+	start, end := sectionBounds(lines, section)
+	if start < 0 {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		return joinLines(append(lines, body...))
+	}
+
+	// Keep the blank line that separates this section from the next one.
+	if end < len(lines) {
+		body = append(body, "")
+	}
+
+	return joinLines(append(lines[:start], append(body, lines[end:]...)...))
+}
+
+// removeSection returns the INI contents without the named section.
+func removeSection(contents, section string) string {
+	lines := splitLines(contents)
+	start, end := sectionBounds(lines, section)
+	if start < 0 {
+		return contents
+	}
+
+	// Drop the blank line that separated the section from the previous one.
+	for start > 0 && strings.TrimSpace(lines[start-1]) == "" {
+		start--
+	}
+
+	return joinLines(append(lines[:start], lines[end:]...))
+}
+
+// sectionValues returns the keys and values set in the named section of the
+// INI contents, or nil if the section is not present. Comments and indented
+// sub-properties are ignored.
+func sectionValues(contents, section string) map[string]string {
+	lines := splitLines(contents)
+	start, end := sectionBounds(lines, section)
+	if start < 0 {
+		return nil
+	}
+
+	values := map[string]string{}
+	for _, line := range lines[start+1 : end] {
+		if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		key, val, ok := strings.Cut(line, "=")
+		if ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(val)
+		}
+	}
+	return values
+}
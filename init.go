@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/spf13/cobra"
+)
+
+var (
+	withHook      bool
+	hookPrompt    bool
+	refreshWithin time.Duration
+)
+
+// zshInit defines cred-use, which exports credentials into the current shell
+// and remembers its arguments for the hook.
+const zshInit = `cred-use() {
+  local out
+  out="$(command cred "$@")" || return
+  typeset -ga _cred_args=("$@")
+  eval "$out"
+}
+`
+
+// zshHook refreshes credentials from precmd once they are close to expiry.
+// The expiry is checked in zsh, so no process is started and STS is not
+// called until a refresh is due.
+const zshHook = `zmodload zsh/datetime
+_cred_precmd() {
+  (( ${+_cred_args} )) || return
+  local expires=${%[1]s} exp
+  [[ -n $expires ]] || return
+  TZ=UTC strftime -r -s exp '%%Y-%%m-%%dT%%H:%%M:%%SZ' "$expires" 2>/dev/null || return
+  if (( exp - EPOCHSECONDS < %[2]d )); then
+    cred-use "${_cred_args[@]}"
+  fi
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook precmd _cred_precmd
+`
+
+// zshPrompt shows the account and expiry time on the right of the prompt.
+const zshPrompt = `setopt prompt_subst
+RPROMPT='${%[1]s:+aws:${%[1]s}${%[2]s:+ until ${%[2]s#*T}}}'
+`
+
+var initCmd = &cobra.Command{
+	Use:       "init zsh",
+	Short:     "Print shell integration for cred",
+	Long:      wordwrap.WrapString("Print shell integration for cred. Add `eval \"$(cred init zsh)\"` to your ~/.zshrc.\n\nThe snippet defines cred-use, which takes the same arguments as cred and exports the credentials into the current shell. With --with-hook, it also refreshes the credentials from a precmd hook shortly before they expire, using the arguments of the last cred-use. The hook only starts cred once a refresh is due.", 80),
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"zsh"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		snippet := []string{zshInit}
+		if withHook {
+			snippet = append(snippet, fmt.Sprintf(zshHook, name(sessionExpiresAt), int(refreshWithin.Seconds())))
+		}
+		if hookPrompt {
+			snippet = append(snippet, fmt.Sprintf(zshPrompt, name(accountID), name(sessionExpiresAt)))
+		}
+		fmt.Print(strings.Join(snippet, "\n"))
+		return nil
+	},
+}
+
+func init() {
+	initCmd.Flags().BoolVar(&withHook, "with-hook", false, "Also refresh credentials from a precmd hook when they are about to expire")
+	initCmd.Flags().DurationVar(&refreshWithin, "refresh-within", 5*time.Minute, "How long before expiry the hook refreshes credentials")
+	initCmd.Flags().BoolVar(&hookPrompt, "prompt", false, "Also show the account and expiry time in RPROMPT")
+
+	rootCmd.AddCommand(initCmd)
+}
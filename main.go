@@ -1,29 +1,68 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/processcreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/aws/smithy-go"
 	"github.com/mitchellh/go-wordwrap"
 	"github.com/spf13/cobra"
 )
 
-var profile string
+// version is set at build time by goreleaser.
+var version = "dev"
+
+// expiringSoonExitCode is the exit status for credentials that were exported
+// but expire within --warn-within.
+const expiringSoonExitCode = 10
+
+var (
+	profile         string
+	prefix          string
+	formatTemplate  string
+	accountAlias    bool
+	onlyIfChanged   bool
+	specFile        string
+	credentialsFile string
+	maxDurationAuto bool
+	profileFallback []string
+	profileCI       bool
+	timeout         time.Duration
+	deadline        string
+	legacyToken     bool
+	exportTTL       bool
+	exportSessionID bool
+	alsoWrite       string
+	jsonOut         string
+	cleanUnresolved bool
+
+	expiryWarningExit bool
+	warnWithin        time.Duration
+)
 
 const (
 	accessKeyID      = "AWS_ACCESS_KEY_ID"
 	secretAccessKey  = "AWS_SECRET_ACCESS_KEY"
 	sessionToken     = "AWS_SESSION_TOKEN"
+	securityToken    = "AWS_SECURITY_TOKEN"
 	sessionExpiresAt = "AWS_SESSION_EXPIRES_AT"
+	sessionTTL       = "AWS_SESSION_TTL_SECONDS"
+	credSessionID    = "AWS_CRED_SESSION_ID"
 	accountID        = "AWS_ACCOUNT_ID"
+	accountAliasVar  = "AWS_ACCOUNT_ALIAS"
 	defaultRegion    = "AWS_DEFAULT_REGION"
 	region           = "AWS_REGION"
 )
@@ -33,19 +72,66 @@ func allVars() []string {
 		accessKeyID,
 		secretAccessKey,
 		sessionToken,
+		securityToken,
 		sessionExpiresAt,
+		sessionTTL,
+		credSessionID,
 		accountID,
+		accountAliasVar,
 		defaultRegion,
 		region,
 	}
 }
 
-func set(key, val string) string {
-	return fmt.Sprintf("%s=%s", key, val)
+// name returns the environment variable name for key, including any
+// namespace prefix requested with --prefix.
+func name(key string) string {
+	return prefix + key
+}
+
+// variable is an environment variable that cred will set.
+type variable struct {
+	Name  string
+	Value string
+
+	// key is the variable's name without any prefix.
+	key string
+}
+
+func set(key, val string) variable {
+	return variable{Name: name(key), Value: val, key: key}
 }
 
 func unset(key string) string {
-	return fmt.Sprintf("unset %s;", key)
+	return name(key)
+}
+
+// script renders the shell statements that unset and then export the given
+// variables.
+func script(exports []variable, unsets []string) string {
+	lines := []string{}
+	for _, key := range unsets {
+		lines = append(lines, fmt.Sprintf("unset %s;", key))
+	}
+
+	if len(exports) > 0 {
+		assignments := []string{}
+		for _, v := range exports {
+			assignments = append(assignments, fmt.Sprintf("%s=%s", v.Name, v.Value))
+		}
+		lines = append(lines, fmt.Sprintf("export %s", strings.Join(assignments, " ")))
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+var validPrefix = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func validatePrefix(cmd *cobra.Command, args []string) error {
+	if prefix != "" && !validPrefix.MatchString(prefix) {
+		return fmt.Errorf("Invalid prefix %q: must contain only letters, digits and underscores, and must not start with a digit", prefix)
+	}
+	return nil
 }
 
 func getCallerIdentity(ctx context.Context, cfg aws.Config) (*sts.GetCallerIdentityOutput, error) {
@@ -61,78 +147,358 @@ func getCallerIdentity(ctx context.Context, cfg aws.Config) (*sts.GetCallerIdent
 	return data, nil
 }
 
+// sessionScope is the session policy and source IP addresses that the
+// credential flags scope the credentials to.
+type sessionScope struct {
+	policy json.RawMessage
+	cidrs  []string
+}
+
+// readSessionScope reads the session policy and looks up the source IP
+// addresses. It is called once per run, because --policy-stdin consumes stdin
+// and --scope-to-my-ip makes a request, however many profiles are tried.
+func readSessionScope(ctx context.Context) (sessionScope, error) {
+	policy, err := readPolicy()
+	if err != nil {
+		return sessionScope{}, err
+	}
+
+	cidrs, err := sourceCIDRs(ctx)
+	if err != nil {
+		return sessionScope{}, err
+	}
+
+	return sessionScope{policy: policy, cidrs: cidrs}, nil
+}
+
+// loadConfig resolves the AWS configuration selected by the credential flags,
+// scoped to scope, ignoring any credentials that are already exported in the
+// environment.
+func loadConfig(ctx context.Context, scope sessionScope) (aws.Config, error) {
+	var (
+		chain *spec
+		err   error
+	)
+	if specFile != "" {
+		if chain, err = loadSpec(specFile); err != nil {
+			return aws.Config{}, err
+		}
+	}
+
+	if policy := scope.policy; policy != nil {
+		if chain == nil {
+			return aws.Config{}, fmt.Errorf("A session policy can only be used with --spec")
+		}
+		if err := chain.applyPolicy(policy); err != nil {
+			return aws.Config{}, err
+		}
+	}
+
+	var profilePolicy json.RawMessage
+	if cidrs := scope.cidrs; len(cidrs) > 0 {
+		if chain != nil {
+			last := &chain.Chain[len(chain.Chain)-1]
+			if last.Policy, err = scopePolicy(last.Policy, cidrs); err != nil {
+				return aws.Config{}, err
+			}
+		} else if profilePolicy, err = scopePolicy(nil, cidrs); err != nil {
+			return aws.Config{}, err
+		}
+	}
+
+	// Copy-pasted profile names often pick up stray whitespace.
+	profile = strings.TrimSpace(profile)
+	if profile == "" && accountMapFile == "" {
+		profile = envProfile()
+	}
+
+	if profileCI && profile != "" && accountMapFile == "" {
+		match, err := matchProfileCI(profile)
+		if err != nil {
+			return aws.Config{}, err
+		}
+		if match != profile {
+			fmt.Fprintf(stderr, "Using profile %s\n", match)
+			profile = match
+		}
+	}
+
+	// loaded is set to the loaded config, for credential sources that need
+	// to make clients from it.
+	var loaded aws.Config
+
+	// These apply to every AWS client, including those cred makes itself.
+	clientOpts, err := endpointOptions()
+	if err != nil {
+		return aws.Config{}, err
+	}
+	clientOpts = append(clientOpts, traceOptions()...)
+
+	opts := []func(*config.LoadOptions) error{}
+	if accountMapFile != "" {
+		// The profile names an account in the map, not a config profile.
+		mapOpts, err := accountMapOptions(ctx, accountMapFile, profile, clientOpts)
+		if err != nil {
+			return aws.Config{}, err
+		}
+		opts = append(opts, mapOpts...)
+	} else if profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	}
+
+	if vaultPath != "" {
+		opts = append(opts, vaultOptions(vaultPath, &loaded)...)
+	}
+
+	if err := expandRegionFlags(); err != nil {
+		return aws.Config{}, err
+	}
+	if regionFlag != "" {
+		opts = append(opts, config.WithRegion(regionFlag))
+	}
+
+	if credentialsFile != "" {
+		fileOpts, err := credentialsFileOptions(ctx, credentialsFile, profile)
+		if err != nil {
+			return aws.Config{}, err
+		}
+		opts = append(opts, fileOpts...)
+	}
+
+	opts = append(opts, clientOpts...)
+
+	if awsDir != "" {
+		dirOpts, err := awsDirOptions()
+		if err != nil {
+			return aws.Config{}, err
+		}
+		opts = append(opts, dirOpts...)
+	}
+
+	for _, key := range allVars() {
+		os.Setenv(key, "")
+	}
+
+	sourceID, err := sourceIdentity()
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	opts = append(opts, config.WithAssumeRoleCredentialOptions(func(o *stscreds.AssumeRoleOptions) {
+		o.TokenProvider = mfaTokenProvider
+		if sourceID != "" {
+			o.SourceIdentity = aws.String(sourceID)
+		}
+		if profilePolicy != nil {
+			o.Policy = aws.String(string(profilePolicy))
+		}
+	}))
+
+	opts = append(opts, sourceTimeoutOptions(&loaded)...)
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	var notExist config.SharedConfigProfileNotExistError
+	if errors.As(err, &notExist) && notExist.Profile == profile {
+		return aws.Config{}, profileNotFound(profile)
+	}
+	if err != nil {
+		return aws.Config{}, err
+	}
+	cfg.Credentials = redactCredentials(cfg.Credentials)
+
+	if cfg.Region == "" {
+		cfg.Region = sourceProfileRegion(cfg)
+	}
+	loaded = cfg
+
+	if err := checkStrictRegion(cfg); err != nil {
+		return aws.Config{}, err
+	}
+
+	if err := checkMFA(cfg, chain); err != nil {
+		return aws.Config{}, err
+	}
+
+	if profilePolicy != nil {
+		if err := checkSourceIPProfile(cfg); err != nil {
+			return aws.Config{}, err
+		}
+	}
+
+	if showConfig != "" {
+		if err := printResolvedConfig(stderr, cfg); err != nil {
+			return aws.Config{}, err
+		}
+	}
+
+	if showChain {
+		printRoleChain(stderr, roleChain(cfg, chain))
+	}
+
+	if verifyChain {
+		fmt.Fprintln(stderr, "Verifying role chain:")
+		// Account maps and Vault replace the profile's credentials.
+		if accountMapFile == "" && vaultPath == "" {
+			if err := verifyProfileChain(ctx, cfg, opts); err != nil {
+				return aws.Config{}, err
+			}
+		}
+		if err := verifyStep(ctx, cfg, "profile "+cmp.Or(profile, "default")); err != nil {
+			return aws.Config{}, err
+		}
+	}
+
+	if chain != nil {
+		if cfg, err = chain.assume(ctx, cfg, sourceID); err != nil {
+			return aws.Config{}, err
+		}
+	}
+
+	if printPolicyContext {
+		pc, err := newPolicyContext(ctx, cfg, chain, sourceID)
+		if err != nil {
+			return aws.Config{}, err
+		}
+		pc.print(stderr)
+	}
+
+	return cfg, nil
+}
+
+// addCredentialFlags registers the flags that select which credentials to
+// resolve on cmd.
+func addCredentialFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&profile, "profile", "", "AWS profile to use")
+	cmd.Flags().StringSliceVar(&profileFallback, "profile-fallback", nil, "Comma-separated profiles to try in order, using the first that resolves valid credentials")
+	cmd.Flags().StringVar(&regionFlag, "region", "", "Use this region instead of the profile's")
+	cmd.Flags().BoolVar(&strictRegion, "strict-region", false, "Fail if --region, the region environment variables and the profile disagree about the region")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "Give up resolving credentials for a profile after this long, e.g. 10s")
+	cmd.Flags().StringVar(&deadline, "deadline", "", "Give up resolving credentials at this time, e.g. 2024-01-01T15:00:00Z")
+	cmd.Flags().DurationVar(&imdsTimeout, "imds-timeout", 0, "Give up getting instance role credentials from IMDS after this long")
+	cmd.Flags().DurationVar(&ssoTimeout, "sso-timeout", 0, "Give up getting role credentials from SSO after this long")
+	cmd.Flags().DurationVar(&processTimeout, "process-timeout", processcreds.DefaultTimeout, "Give up on a credential_process after this long")
+	cmd.Flags().StringVar(&onErrorExec, "on-error-exec", "", "Run this shell command if resolving credentials fails, e.g. to log in again, with the error in $CRED_ERROR and $CRED_ERROR_CATEGORY")
+	cmd.Flags().BoolVar(&onErrorRetry, "on-error-retry", false, "Retry once if the --on-error-exec command succeeds")
+	cmd.MarkFlagsMutuallyExclusive("profile", "profile-fallback")
+	cmd.Flags().BoolVar(&profileCI, "profile-ci", false, "Match the profile name ignoring case")
+	cmd.Flags().StringVar(&credentialsFile, "credentials-file", "", "Path to a standalone credentials file to read the profile from")
+	cmd.Flags().StringVar(&accountMapFile, "account-map", "", "Path to a JSON file mapping profile names to SSO accounts and roles")
+	cmd.Flags().StringVar(&awsDir, "aws-dir", "", "Read the config and credentials files and the SSO token cache from this directory instead of ~/.aws")
+	cmd.MarkFlagsMutuallyExclusive("credentials-file", "account-map", "aws-dir")
+	cmd.Flags().StringVar(&endpointURL, "endpoint-url", "", "Send all AWS requests to this URL, e.g. a LocalStack endpoint")
+	cmd.Flags().BoolVar(&insecureSkipVerify, "insecure-skip-verify", false, "Do not verify the TLS certificate of --endpoint-url, for testing only")
+	cmd.Flags().StringVar(&minTLSVersion, "min-tls-version", "", "Require at least this TLS version for AWS requests, e.g. 1.3")
+	cmd.Flags().StringVar(&specFile, "spec", "", "Path to a JSON file describing a chain of roles to assume")
+	cmd.Flags().StringVar(&policyFile, "policy-file", "", "Path to a session policy for the last role in the spec")
+	cmd.Flags().BoolVar(&policyStdin, "policy-stdin", false, "Read a session policy for the last role in the spec from stdin")
+	cmd.Flags().StringSliceVar(&scopeToIP, "scope-to-ip", nil, "Only allow the final session to be used from these comma-separated IP addresses or CIDR blocks")
+	cmd.Flags().BoolVar(&scopeToMyIP, "scope-to-my-ip", false, "Only allow the final session to be used from this host's public IP address")
+	cmd.Flags().StringVar(&showConfig, "show-config", "", "Print the resolved configuration to stderr, with secrets masked, as text or json")
+	cmd.Flags().Lookup("show-config").NoOptDefVal = "text"
+	cmd.Flags().BoolVar(&showChain, "show-chain", false, "Print the chain of roles that will be assumed to stderr")
+	cmd.Flags().BoolVar(&verifyChain, "verify-chain", false, "Check the credentials at every step of the role chain with GetCallerIdentity, reporting each ARN to stderr")
+	cmd.Flags().BoolVar(&printPolicyContext, "print-policy-context", false, "Print the caller ARN, session tags and session policies of the final session to stderr")
+	cmd.Flags().StringVar(&sourceIdentityFlag, "source-identity", "", "Set this SourceIdentity when assuming roles")
+	cmd.Flags().BoolVar(&sourceIdentityFromUser, "source-identity-from-user", false, "Set the local username as the SourceIdentity when assuming roles")
+	cmd.MarkFlagsMutuallyExclusive("source-identity", "source-identity-from-user")
+	cmd.Flags().BoolVar(&maxDurationAuto, "max-duration-auto", false, "Request each role's maximum session duration when the spec does not set one")
+	cmd.Flags().BoolVar(&durationProbe, "assume-role-duration-probe", false, "Find the first role's maximum session duration by retrying AssumeRole with shorter durations, when the spec does not set one")
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "cred",
 	Short: "Fetch AWS credentials and set them as environment variables",
 	Long:  wordwrap.WrapString("Fetch AWS credentials and set them as environment variables.\n\nEvaluate the output of the command in order to export AWS credentials as environment variables, e.g. $(cred) or eval $(cred).", 80),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := validatePrefix(cmd, args); err != nil {
+			return err
+		}
+		if err := validateTZ(); err != nil {
+			return err
+		}
+		if err := validateEndpoint(); err != nil {
+			return err
+		}
+		return validateFormat(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 
-		opts := []func(*config.LoadOptions) error{}
-		if profile != "" {
-			opts = append(opts, config.WithSharedConfigProfile(profile))
+		var tmpl *template.Template
+		if formatTemplate != "" {
+			var err error
+			if tmpl, err = loadTemplate(formatTemplate); err != nil {
+				return err
+			}
 		}
 
-		for _, key := range allVars() {
-			os.Setenv(key, "")
+		if err := checkFishUniversal(); err != nil {
+			return err
 		}
 
-		cfg, err := config.LoadDefaultConfig(ctx, opts...)
-		if err != nil {
+		if err := validateAlsoWrite(); err != nil {
 			return err
 		}
 
-		creds, err := cfg.Credentials.Retrieve(ctx)
+		res, err := resolve(ctx)
 		if err != nil {
 			return err
 		}
 
-		data, err := getCallerIdentity(ctx, cfg)
-		if err != nil {
-			return err
+		if res.unchanged {
+			return nil
 		}
 
-		unsets := []string{}
+		if alsoWrite != "" {
+			if err := writeProfile(credentialsFilePath(), alsoWrite, profileValues(res.creds)); err != nil {
+				return err
+			}
+		}
 
-		exports := []string{
-			set(accessKeyID, creds.AccessKeyID),
-			set(secretAccessKey, creds.SecretAccessKey),
+		if writeCLICache {
+			if err := writeCLICacheEntry(res); err != nil {
+				return err
+			}
 		}
 
-		if creds.AccountID != "" {
-			exports = append(exports, set(accountID, creds.AccountID))
-		} else {
-			exports = append(exports, set(accountID, *data.Account))
+		if jsonOut != "" {
+			if err := writeFileIfChanged(jsonOut, []byte(credentialProcess(res.exports, nil))); err != nil {
+				return fmt.Errorf("Failed to write --json-out file: %w", err)
+			}
 		}
 
-		if cfg.Region != "" {
-			exports = append(exports, set(defaultRegion, cfg.Region))
-			exports = append(exports, set(region, cfg.Region))
-		} else {
-			unsets = append(unsets, unset(defaultRegion))
-			unsets = append(unsets, unset(region))
+		sortOutput(res.exports, res.unsets)
+
+		if tmpl != nil {
+			output, err := renderTemplate(tmpl, templateData{
+				Credentials:  res.creds,
+				AccountID:    res.account,
+				AccountAlias: res.alias,
+				Region:       res.cfg.Region,
+				Sets:         res.exports,
+				Unsets:       res.unsets,
+			})
+			if err != nil {
+				return err
+			}
+			return emit(output)
 		}
 
-		if creds.SessionToken != "" {
-			exports = append(
-				exports,
-				set(sessionToken, creds.SessionToken),
-				set(sessionExpiresAt, creds.Expires.Format(time.RFC3339)),
-			)
-		} else {
-			unsets = append(
-				unsets,
-				unset(sessionToken),
-				unset(sessionExpiresAt),
-			)
+		output := formats[format].render(res.exports, res.unsets)
+		if format == "sh" && wantComment() {
+			output += summaryComment(res)
 		}
 
-		output := fmt.Sprintf("export %s\n", strings.Join(exports, " "))
-		if len(unsets) > 0 {
-			output = fmt.Sprintf("%s\n%s", strings.Join(unsets, "\n"), output)
+		if err := emit(output); err != nil {
+			return err
 		}
-		fmt.Print(output)
 
+		rememberFormat(format)
+
+		if expiryWarningExit && expiresWithin(res.creds, warnWithin) {
+			fmt.Fprintf(stderr, "Warning: the credentials expire in less than %s, at %s\n", warnWithin, displayClock(res.creds.Expires))
+			os.Exit(expiringSoonExitCode)
+		}
 		return nil
 	},
 }
@@ -143,18 +509,18 @@ var expiryCmd = &cobra.Command{
 	Aliases: []string{"exp", "expires", "expire"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		switch {
-		case os.Getenv("AWS_ACCESS_KEY_ID") == "":
+		case os.Getenv(name(accessKeyID)) == "":
 			return fmt.Errorf("AWS credentials are not set as environment variables")
-		case os.Getenv("AWS_SESSION_TOKEN") == "":
+		case os.Getenv(name(sessionToken)) == "":
 			return fmt.Errorf("AWS credentials in environment variables are not temporary")
-		case os.Getenv("AWS_SESSION_EXPIRES_AT") == "":
+		case os.Getenv(name(sessionExpiresAt)) == "":
 			return fmt.Errorf("AWS credentials expiration time has not been recorded in your environment")
 		default:
-			expires, err := time.Parse(time.RFC3339, os.Getenv("AWS_SESSION_EXPIRES_AT"))
+			expires, err := time.Parse(time.RFC3339, os.Getenv(name(sessionExpiresAt)))
 			if err != nil {
 				return fmt.Errorf("AWS credentials expiration time has not been properly recorded in your environment")
 			}
-			fmt.Println(expires.Local().Format(time.RFC1123))
+			fmt.Println(displayTime(expires).Format(time.RFC1123))
 			return nil
 		}
 	},
@@ -166,24 +532,79 @@ var clearCmd = &cobra.Command{
 	Long:    wordwrap.WrapString("Clear AWS environment variables.\n\nEvaluate the output of the command in order to export AWS credentials as environment variables, e.g. $(cred clear) or eval $(cred clear).", 80),
 	Aliases: []string{"unset", "rm", "none"},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("format") {
+			format = lastFormat()
+		}
+		if err := checkFishUniversal(); err != nil {
+			return err
+		}
+
 		unsets := []string{}
 		for _, key := range allVars() {
 			unsets = append(unsets, unset(key))
 		}
 
-		fmt.Println(strings.Join(unsets, "\n"))
-		return nil
+		_, unsets = withoutContextVars(nil, unsets)
+		sortOutput(nil, unsets)
+
+		if formatTemplate != "" {
+			tmpl, err := loadTemplate(formatTemplate)
+			if err != nil {
+				return err
+			}
+			output, err := renderTemplate(tmpl, templateData{Unsets: unsets})
+			if err != nil {
+				return err
+			}
+			return emit(output)
+		}
+
+		return emit(formats[format].render(nil, unsets))
 	},
 }
 
 func main() {
+	rootCmd.SetErr(stderr)
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
 
 func init() {
-	rootCmd.Flags().StringVar(&profile, "profile", "", "AWS profile to use")
+	addCredentialFlags(rootCmd)
+	addGuardFlags(rootCmd)
+	rootCmd.Flags().BoolVar(&exportTTL, "export-ttl", false, "Also export AWS_SESSION_TTL_SECONDS, the seconds until the credentials expire at the time they were fetched")
+	rootCmd.Flags().BoolVar(&exportSessionID, "session-id", false, "Also export AWS_CRED_SESSION_ID, a non-secret ID of the session for correlating logs")
+	rootCmd.Flags().BoolVar(&legacyToken, "legacy-token", false, "Also export the session token as AWS_SECURITY_TOKEN for legacy SDKs")
+	rootCmd.Flags().BoolVar(&lockRegion, "lock-region", false, "Never unset the region variables, even if no region is configured")
+	rootCmd.Flags().StringArrayVar(&regionSets, "region-set", nil, "Also export LABEL_AWS_REGION for LABEL=region, can be repeated")
+	rootCmd.Flags().StringVar(&alsoWrite, "also-write", "", "Also write the credentials to this profile in your credentials file")
+	rootCmd.Flags().StringVar(&jsonOut, "json-out", "", "Also write the credentials to this file as a credential_process JSON document")
+	rootCmd.Flags().BoolVar(&printSummary, "summary", false, "Print a one-line summary of the resolved credentials to stderr, without secrets")
+	rootCmd.Flags().StringVar(&traceOut, "trace-out", "", "Write a JSON timing summary of resolving the credentials and each AWS call to this file, without secrets")
+	rootCmd.Flags().BoolVar(&cleanUnresolved, "clean-unresolved", false, "Unset every variable cred manages that it does not set, so no stale values linger")
+	rootCmd.Flags().BoolVar(&onlyCredentials, "emit-only-credentials", false, "Only set the credential and expiry variables, leaving the account and region variables alone")
+	rootCmd.Flags().BoolVar(&writeCLICache, "write-cli-cache", false, "Also save the session in the AWS CLI's cache, so the CLI and boto3 reuse it for the profile")
+	rootCmd.Flags().BoolVar(&expiryWarningExit, "expiry-warning-exit", false, fmt.Sprintf("Exit with status %d after printing the credentials if they expire within --warn-within", expiringSoonExitCode))
+	rootCmd.Flags().DurationVar(&warnWithin, "warn-within", 10*time.Minute, "How soon the credentials must expire for --expiry-warning-exit")
+	rootCmd.Flags().BoolVar(&verifyRegion, "verify-region", false, "Warn about --region-set regions whose STS endpoint rejects the credentials")
+	rootCmd.Flags().BoolVar(&accountAlias, "account-alias", false, "Look up the account alias and export it as AWS_ACCOUNT_ALIAS")
+	rootCmd.Flags().BoolVar(&onlyIfChanged, "output-only-if-changed", false, "Print nothing if the credentials are already set in the environment")
+	rootCmd.Flags().BoolVar(&comment, "comment", false, "Append a comment describing the credentials, even when output is not a terminal")
+	rootCmd.Flags().BoolVar(&noComment, "no-comment", false, "Never append a comment describing the credentials")
+	rootCmd.MarkFlagsMutuallyExclusive("comment", "no-comment")
+	rootCmd.Flags().StringVar(&formatTemplate, "format-template", "", "Path to a Go text/template file used to render the output")
+	clearCmd.Flags().StringVar(&formatTemplate, "format-template", "", "Path to a Go text/template file used to render the output")
+	clearCmd.Flags().BoolVar(&onlyCredentials, "emit-only-credentials", false, "Only unset the credential and expiry variables, leaving the account and region variables alone")
+	addFormatFlags(rootCmd)
+	addFormatFlags(clearCmd)
+	rootCmd.PersistentFlags().StringVar(&prefix, "prefix", "", "Prefix to add to the name of every environment variable, e.g. DEV_")
+	rootCmd.PersistentFlags().StringVar(&accountNameMapFile, "account-name-map", "", "Path to a JSON file mapping account IDs to friendly names to display")
+	rootCmd.PersistentFlags().BoolVar(&noAccountName, "no-account-name", false, "Never display friendly account names")
+	rootCmd.PersistentFlags().StringVar(&displayTZ, "tz", "local", "Time zone to display times in, local or utc")
+	rootCmd.MarkFlagsMutuallyExclusive("emit-only-credentials", "region-set")
+	rootCmd.MarkFlagsMutuallyExclusive("emit-only-credentials", "account-alias")
+	rootCmd.MarkFlagsMutuallyExclusive("emit-only-credentials", "lock-region")
 
 	rootCmd.AddCommand(expiryCmd)
 	rootCmd.AddCommand(clearCmd)
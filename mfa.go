@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+var requireMFA bool
+
+// mfaTokenProvider prompts for an MFA code on stderr, so that the prompt does
+// not end up in output that is being evaluated.
+func mfaTokenProvider() (string, error) {
+	fmt.Fprint(stderr, "MFA token code: ")
+	code, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && code == "" {
+		return "", fmt.Errorf("Failed to read MFA token code: %w", err)
+	}
+	return strings.TrimSpace(code), nil
+}
+
+// usesMFA reports whether the final credentials come from a role assumed
+// with an MFA device. This is worked out from configuration: it cannot tell
+// whether the source credentials were themselves MFA-authenticated.
+func usesMFA(cfg aws.Config, s *spec) bool {
+	if s != nil {
+		return s.Chain[len(s.Chain)-1].MFASerial != ""
+	}
+
+	for _, src := range cfg.ConfigSources {
+		if shared, ok := src.(config.SharedConfig); ok && shared.RoleARN != "" {
+			return shared.MFASerial != ""
+		}
+	}
+
+	return false
+}
+
+// checkMFA fails if --require-mfa was given and the final credentials would
+// not be obtained with MFA.
+func checkMFA(cfg aws.Config, s *spec) error {
+	if requireMFA && !usesMFA(cfg, s) {
+		return errors.New("--require-mfa is set, but the credentials are not obtained by assuming a role with mfa_serial")
+	}
+	return nil
+}
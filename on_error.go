@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/smithy-go"
+)
+
+var (
+	onErrorExec  string
+	onErrorRetry bool
+)
+
+// startupEnv is the environment cred started with, before loadConfig blanked
+// the variables it manages, so that hooks see the environment they would in
+// the shell.
+var startupEnv = os.Environ()
+
+// errorCategory is a short name for why resolving credentials failed, for
+// --on-error-exec hooks to act on.
+func errorCategory(err error) string {
+	var notFound profileNotFoundError
+	var invalidToken *ssocreds.InvalidTokenError
+	var apiErr smithy.APIError
+
+	switch {
+	case errors.As(err, &notFound):
+		return "profile-not-found"
+	case errors.As(err, &invalidToken):
+		return "sso-login"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &apiErr):
+		switch apiErr.ErrorCode() {
+		case "ExpiredToken", "ExpiredTokenException":
+			return "expired-token"
+		case "AccessDenied", "AccessDeniedException":
+			return "access-denied"
+		}
+	}
+	return "other"
+}
+
+// recoverResolve runs the --on-error-exec hook after resolving credentials
+// failed with err, and with --on-error-retry, tries once more if the hook
+// succeeded. The hook's output goes to stderr, so it never ends up in the
+// exported variables.
+func recoverResolve(ctx context.Context, current map[string]string, scope sessionScope, err error) (*resolution, error) {
+	hook := exec.CommandContext(ctx, "sh", "-c", onErrorExec)
+	hook.Stdin = os.Stdin
+	hook.Stdout = os.Stderr
+	hook.Stderr = os.Stderr
+	hook.Env = append(slices.Clone(startupEnv),
+		"CRED_ERROR="+err.Error(),
+		"CRED_ERROR_CATEGORY="+errorCategory(err),
+		"CRED_ERROR_PROFILE="+profile,
+	)
+
+	if hookErr := hook.Run(); hookErr != nil {
+		return nil, fmt.Errorf("%w\nThe --on-error-exec command also failed: %v", err, hookErr)
+	}
+
+	if !onErrorRetry {
+		return nil, err
+	}
+
+	fmt.Fprintln(stderr, "The --on-error-exec command succeeded; retrying")
+	res, retryErr := resolveFallback(ctx, current, scope)
+	if retryErr != nil {
+		return nil, fmt.Errorf("%w\nBefore --on-error-exec ran, the first attempt failed with: %v", retryErr, err)
+	}
+	return res, nil
+}
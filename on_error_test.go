@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOnErrorExecEnvironment(t *testing.T) {
+	old := startupEnv
+	t.Cleanup(func() { startupEnv = old })
+	startupEnv = []string{"PATH=" + os.Getenv("PATH"), "AWS_REGION=eu-west-1", "AWS_CONFIG_FILE=/etc/aws/config"}
+
+	f := newFakeAWS(t)
+	f.handle["GetCallerIdentity"] = func(r *http.Request) (int, string) {
+		return http.StatusForbidden, fakeError("ExpiredToken", "The security token included in the request is expired")
+	}
+
+	envFile := filepath.Join(t.TempDir(), "env")
+	out, err := runCred(t, f, "--on-error-exec", "env > "+envFile)
+	if err == nil {
+		t.Fatalf("expected an error, stderr:\n%s", out)
+	}
+
+	data, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	for _, want := range []string{"AWS_REGION=eu-west-1", "AWS_CONFIG_FILE=/etc/aws/config", "CRED_ERROR_PROFILE=test"} {
+		if !slices.Contains(env, want) {
+			t.Errorf("the hook's environment does not contain %s:\n%s", want, data)
+		}
+	}
+	for _, v := range env {
+		if strings.HasPrefix(v, accessKeyID+"=") || strings.HasPrefix(v, defaultRegion+"=") {
+			t.Errorf("the hook's environment contains %s", v)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	pingRegions     []string
+	pingCallTimeout time.Duration
+	pingJSON        bool
+)
+
+// pingConcurrency bounds how many regional endpoints are probed at once.
+const pingConcurrency = 4
+
+// pingResult is the outcome of probing one regional STS endpoint.
+type pingResult struct {
+	Region    string `json:"region"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+var pingCmd = &cobra.Command{
+	Use:   "ping",
+	Short: "Measure how long STS takes to validate your credentials",
+	Long:  wordwrap.WrapString("Measure how long STS takes to validate your credentials.\n\nBy default this calls GetCallerIdentity in the resolved region. Pass --regions to probe several regional STS endpoints concurrently, which helps to diagnose region-specific network issues.", 80),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		for i, r := range pingRegions {
+			var err error
+			if pingRegions[i], err = expandRegion(r); err != nil {
+				return fmt.Errorf("Invalid --regions: %w", err)
+			}
+		}
+
+		scope, err := readSessionScope(ctx)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig(ctx, scope)
+		if err != nil {
+			return err
+		}
+
+		// Resolve credentials up front so that only STS is measured.
+		if _, err := cfg.Credentials.Retrieve(ctx); err != nil {
+			return err
+		}
+
+		regions := pingRegions
+		if len(regions) == 0 {
+			regions = []string{cfg.Region}
+		}
+
+		results := make([]pingResult, len(regions))
+
+		g := new(errgroup.Group)
+		g.SetLimit(pingConcurrency)
+		for i, r := range regions {
+			g.Go(func() error {
+				callCtx, cancel := context.WithTimeout(ctx, pingCallTimeout)
+				defer cancel()
+
+				client := sts.NewFromConfig(cfg, func(o *sts.Options) {
+					if r != "" {
+						o.Region = r
+					}
+				})
+
+				start := time.Now()
+				_, err := client.GetCallerIdentity(callCtx, &sts.GetCallerIdentityInput{})
+				results[i] = pingResult{Region: r, LatencyMS: time.Since(start).Milliseconds()}
+				if err != nil {
+					results[i].Error = err.Error()
+				}
+				return nil
+			})
+		}
+		g.Wait()
+
+		if pingJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(results)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "REGION\tLATENCY\tRESULT")
+		for _, r := range results {
+			region, result := r.Region, "ok"
+			if region == "" {
+				region = "(global)"
+			}
+			if r.Error != "" {
+				result = r.Error
+			}
+			fmt.Fprintf(w, "%s\t%dms\t%s\n", region, r.LatencyMS, result)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	addCredentialFlags(pingCmd)
+	pingCmd.Flags().StringSliceVar(&pingRegions, "regions", nil, "Comma-separated regions whose STS endpoints to probe")
+	pingCmd.Flags().DurationVar(&pingCallTimeout, "call-timeout", 5*time.Second, "Give up on a region's endpoint after this long")
+	pingCmd.Flags().BoolVar(&pingJSON, "json", false, "Print the results as JSON")
+
+	rootCmd.AddCommand(pingCmd)
+}
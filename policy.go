@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	policyFile  string
+	policyStdin bool
+)
+
+// readPolicy returns the session policy given with --policy-file or
+// --policy-stdin, or nil if neither was used.
+func readPolicy() (json.RawMessage, error) {
+	var (
+		data   []byte
+		source string
+		err    error
+	)
+
+	switch {
+	case policyFile != "" && policyStdin:
+		return nil, errors.New("Use only one of --policy-file and --policy-stdin")
+	case policyFile != "":
+		source = policyFile
+		data, err = os.ReadFile(policyFile)
+	case policyStdin:
+		source = "stdin"
+		data, err = io.ReadAll(os.Stdin)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read session policy from %s: %w", source, err)
+	}
+
+	if err := validatePolicy(data); err != nil {
+		return nil, fmt.Errorf("Invalid session policy from %s: %w", source, err)
+	}
+
+	return json.RawMessage(bytes.TrimSpace(data)), nil
+}
+
+// validatePolicy checks that data is a JSON object, reporting the line and
+// column of any syntax error.
+func validatePolicy(data []byte) error {
+	var doc map[string]any
+	err := json.Unmarshal(data, &doc)
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, col := position(data, syntaxErr.Offset)
+		return fmt.Errorf("line %d, column %d: %w", line, col, err)
+	}
+	if err != nil {
+		return errors.New("policy must be a JSON object")
+	}
+
+	return nil
+}
+
+// position converts a byte offset in data to a 1-based line and column.
+func position(data []byte, offset int64) (int, int) {
+	before := data[:min(int(offset), len(data))]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// applyPolicy sets policy as the session policy of the last role in the
+// chain, which is the role whose credentials cred exports.
+func (s *spec) applyPolicy(policy json.RawMessage) error {
+	last := &s.Chain[len(s.Chain)-1]
+	if len(last.Policy) > 0 {
+		return errors.New("The last role in the spec already has a policy; remove it or stop passing one on the command line")
+	}
+	last.Policy = policy
+	return nil
+}
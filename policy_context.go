@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+var printPolicyContext bool
+
+// policyContext is what determines the effective permissions of the final
+// session, apart from the policies attached to the role itself.
+type policyContext struct {
+	caller         string
+	sourceIdentity string
+	tags           map[string]string
+	policyARNs     []string
+	inlinePolicy   bool
+}
+
+// newPolicyContext looks up the caller ARN of cfg's credentials, and collects
+// what cred passed to the last AssumeRole call in the spec, if there is one.
+func newPolicyContext(ctx context.Context, cfg aws.Config, s *spec, sourceID string) (*policyContext, error) {
+	data, err := getCallerIdentity(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := &policyContext{caller: aws.ToString(data.Arn), sourceIdentity: sourceID}
+	if s != nil {
+		last := s.Chain[len(s.Chain)-1]
+		pc.tags = last.Tags
+		pc.policyARNs = last.PolicyARNs
+		pc.inlinePolicy = len(last.Policy) > 0
+	}
+	return pc, nil
+}
+
+func (pc *policyContext) print(w io.Writer) {
+	fmt.Fprintln(w, "Policy context:")
+	fmt.Fprintf(w, "  caller: %s\n", pc.caller)
+	if pc.sourceIdentity != "" {
+		fmt.Fprintf(w, "  source identity: %s\n", pc.sourceIdentity)
+	}
+	if len(pc.tags) > 0 {
+		tags := []string{}
+		for _, k := range slices.Sorted(maps.Keys(pc.tags)) {
+			tags = append(tags, fmt.Sprintf("%s=%s", k, pc.tags[k]))
+		}
+		fmt.Fprintf(w, "  session tags: %s\n", strings.Join(tags, ", "))
+	}
+	for _, a := range pc.policyARNs {
+		fmt.Fprintf(w, "  session policy: %s\n", a)
+	}
+	if pc.inlinePolicy {
+		fmt.Fprintln(w, "  session policy: inline")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestPolicyStdinFallback checks that a session policy read from stdin is
+// applied to every profile that --profile-fallback tries, not only the first.
+func TestPolicyStdinFallback(t *testing.T) {
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, "spec.json")
+	writeTestFile(t, specPath, `{"chain": [{"role_arn": "arn:aws:iam::123456789012:role/Admin"}]}`)
+
+	const policy = `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]}`
+	stdinPath := filepath.Join(dir, "stdin")
+	writeTestFile(t, stdinPath, policy+"\n")
+	stdin, err := os.Open(stdinPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	oldStdin := os.Stdin
+	t.Cleanup(func() { os.Stdin = oldStdin })
+	os.Stdin = stdin
+
+	f := newFakeAWS(t)
+	var got string
+	f.handle["AssumeRole"] = func(r *http.Request) (int, string) {
+		got = r.Form.Get("Policy")
+		return http.StatusOK, fakeResponses["AssumeRole"]
+	}
+
+	out, err := runCred(t, f, "--spec", specPath, "--policy-stdin", "--profile-fallback", "broken,test")
+	if err != nil {
+		t.Fatalf("unexpected error %v, stderr:\n%s", err, out)
+	}
+	if !strings.Contains(out, "Using profile test") {
+		t.Errorf("stderr does not report the fallback profile:\n%s", out)
+	}
+	if got != policy {
+		t.Errorf("got policy %q, want %q", got, policy)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// psQuote quotes s as a PowerShell literal string.
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// awsPowerShell renders the credentials as cmdlets of the AWS Tools for
+// PowerShell, which keep their own session credentials and default region
+// instead of reading environment variables.
+func awsPowerShell(exports []variable, unsets []string) string {
+	values := map[string]string{}
+	for _, v := range exports {
+		values[v.key] = v.Value
+	}
+
+	lines := ""
+	if values[accessKeyID] != "" {
+		cmd := fmt.Sprintf("Set-AWSCredential -AccessKey %s -SecretKey %s", psQuote(values[accessKeyID]), psQuote(values[secretAccessKey]))
+		if values[sessionToken] != "" {
+			cmd += " -SessionToken " + psQuote(values[sessionToken])
+		}
+		lines += cmd + "\n"
+	} else if slices.Contains(unsets, unset(accessKeyID)) {
+		lines += "Clear-AWSCredential\n"
+	}
+
+	if values[region] != "" {
+		lines += fmt.Sprintf("Set-DefaultAWSRegion -Region %s\n", psQuote(values[region]))
+	} else if slices.Contains(unsets, unset(region)) {
+		lines += "Clear-DefaultAWSRegion\n"
+	}
+
+	return lines
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// profileNames lists the profiles defined in the shared config and
+// credentials files that cred will read.
+func profileNames() []string {
+	files := map[string]bool{credentialsFilePath(): false, configFilePath(): true}
+	if credentialsFile != "" {
+		files = map[string]bool{credentialsFile: false}
+	}
+
+	names := []string{}
+	for path, isConfig := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		for _, line := range strings.Split(string(data), "\n") {
+			match := sectionHeader.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+
+			section := match[1]
+			if isConfig && section != "default" {
+				// Other sections in the config file, such as sso-session, are
+				// not profiles.
+				var ok bool
+				if section, ok = strings.CutPrefix(section, "profile "); !ok {
+					continue
+				}
+				section = strings.TrimSpace(section)
+			}
+
+			if !slices.Contains(names, section) {
+				names = append(names, section)
+			}
+		}
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// envProfile returns the profile to use when --profile is not given.
+// CRED_PROFILE selects a profile for cred alone, and AWS_DEFAULT_PROFILE is
+// still set by some older setups, though the SDK no longer reads it.
+func envProfile() string {
+	for _, key := range []string{"CRED_PROFILE", "AWS_PROFILE", "AWS_DEFAULT_PROFILE"} {
+		if p := strings.TrimSpace(os.Getenv(key)); p != "" {
+			return p
+		}
+	}
+	return ""
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+
+	return prev[len(b)]
+}
+
+// closestProfile returns the known profile name nearest to name, if any is
+// near enough to be a plausible typo.
+func closestProfile(name string, names []string) (string, bool) {
+	best, bestDistance := "", -1
+	for _, candidate := range names {
+		d := levenshtein(strings.ToLower(name), strings.ToLower(candidate))
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+
+	if bestDistance < 0 || bestDistance > max(2, len(name)/3) {
+		return "", false
+	}
+	return best, true
+}
+
+// didYouMean suggests the existing profile closest to name, for appending to
+// a "profile not found" error.
+func didYouMean(name string) string {
+	if suggestion, ok := closestProfile(name, profileNames()); ok {
+		return fmt.Sprintf("; did you mean %q?", suggestion)
+	}
+	return ""
+}
+
+// profileNotFoundError is the error for a profile that is not in the config
+// or credentials files.
+type profileNotFoundError struct {
+	name string
+}
+
+func (e profileNotFoundError) Error() string {
+	return fmt.Sprintf("Profile %q not found%s", e.name, didYouMean(e.name))
+}
+
+func profileNotFound(name string) error {
+	return profileNotFoundError{name: name}
+}
+
+// matchProfileCI returns the one known profile whose name matches name
+// ignoring case. An exact match always wins.
+func matchProfileCI(name string) (string, error) {
+	matches := []string{}
+	for _, candidate := range profileNames() {
+		if candidate == name {
+			return name, nil
+		}
+		if strings.EqualFold(candidate, name) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", profileNotFound(name)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("Profile %q matches several profiles ignoring case: %s", name, strings.Join(matches, ", "))
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"prod", "prod", 0},
+		{"", "prod", 4},
+		{"prod", "", 4},
+		{"prod", "prd", 1},
+		{"prod", "porrd", 2},
+		{"staging", "stagign", 2},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClosestProfile(t *testing.T) {
+	names := []string{"default", "dev", "prod-admin", "prod-readonly", "staging"}
+
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{name: "stagign", want: "staging", wantOK: true},
+		{name: "prod-admn", want: "prod-admin", wantOK: true},
+		{name: "PROD-ADMIN", want: "prod-admin", wantOK: true},
+		{name: "dve", want: "dev", wantOK: true},
+		{name: "production", wantOK: false},
+		{name: "sandbox", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := closestProfile(tt.name, names)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("got %q, %t, want %q, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	t.Run("no profiles", func(t *testing.T) {
+		if got, ok := closestProfile("prod", nil); ok {
+			t.Errorf("got %q, want no suggestion", got)
+		}
+	})
+}
+
+func TestProfileNames(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "config"), "[default]\n[profile  prod ]\n[ profile dev]\n[sso-session corp]\n")
+	writeTestFile(t, filepath.Join(dir, "credentials"), "[ ci ]\n[prod]\n")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+
+	got := strings.Join(profileNames(), ",")
+	if want := "ci,default,dev,prod"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestProfileFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		wantErr string
+	}{
+		{name: "exact", profile: "test"},
+		{name: "trimmed", profile: "  test\t"},
+		{name: "typo", profile: "tset", wantErr: `Profile "tset" not found; did you mean "test"?`},
+		{name: "trimmed typo", profile: " tets ", wantErr: `Profile "tets" not found; did you mean "test"?`},
+		{name: "unknown", profile: "production", wantErr: `Profile "production" not found`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := runCred(t, newFakeAWS(t), "--profile", tt.profile)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v, stderr:\n%s", err, out)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("got error %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnvProfile(t *testing.T) {
+	tests := []struct {
+		name                                string
+		credProfile, awsProfile, awsDefault string
+		want                                string
+	}{
+		{name: "none"},
+		{name: "AWS_DEFAULT_PROFILE only", awsDefault: "legacy", want: "legacy"},
+		{name: "AWS_PROFILE only", awsProfile: "dev", want: "dev"},
+		{name: "CRED_PROFILE only", credProfile: "ops", want: "ops"},
+		{name: "AWS_PROFILE over AWS_DEFAULT_PROFILE", awsProfile: "dev", awsDefault: "legacy", want: "dev"},
+		{name: "CRED_PROFILE over AWS_PROFILE", credProfile: "ops", awsProfile: "dev", want: "ops"},
+		{name: "CRED_PROFILE over both", credProfile: "ops", awsProfile: "dev", awsDefault: "legacy", want: "ops"},
+		{name: "blank values are skipped", credProfile: "  ", awsProfile: "\t", awsDefault: " legacy ", want: "legacy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CRED_PROFILE", tt.credProfile)
+			t.Setenv("AWS_PROFILE", tt.awsProfile)
+			t.Setenv("AWS_DEFAULT_PROFILE", tt.awsDefault)
+
+			if got := envProfile(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// parseRecipe applies a recipe's flags, followed by any extra flags given on
+// the command line, to the root command.
+func parseRecipe(recipe []string, extra []string) error {
+	if len(recipe) == 0 {
+		return fmt.Errorf("it has no flags")
+	}
+
+	resetFlags(rootCmd)
+	if err := rootCmd.ParseFlags(append(slices.Clone(recipe), extra...)); err != nil {
+		return err
+	}
+	if args := rootCmd.Flags().Args(); len(args) > 0 {
+		return fmt.Errorf("unexpected argument %q; recipes can only contain cred's flags", args[0])
+	}
+	return rootCmd.ValidateFlagGroups()
+}
+
+// resetFlags returns every flag of cmd to its default, so that recipes can be
+// checked one after another.
+func resetFlags(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			sv.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+}
+
+var recipeCmd = &cobra.Command{
+	Use:   "recipe NAME [flags]",
+	Short: "Fetch AWS credentials with a named set of flags from cred's config file",
+	Long:  wordwrap.WrapString("Fetch AWS credentials with a named set of flags from cred's config file.\n\nA recipe packages the flags for assuming a particular role into a memorable name. `cred recipe prod-admin` is the same as running cred with the flags of the prod-admin recipe, followed by any other flags given after the name. Recipes are defined under \"recipes\" in config.json in cred's user config directory.", 80),
+	// The recipe's flags are parsed once its name is known.
+	DisableFlagParsing: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+			return cmd.Help()
+		}
+
+		s, err := loadSettings()
+		if err != nil {
+			return err
+		}
+
+		recipeName := args[0]
+		recipe, ok := s.Recipes[recipeName]
+		if !ok {
+			msg := ""
+			if closest, ok := closestProfile(recipeName, slices.Sorted(maps.Keys(s.Recipes))); ok {
+				msg = fmt.Sprintf("; did you mean %q?", closest)
+			}
+			return fmt.Errorf("Recipe %q not found%s", recipeName, msg)
+		}
+
+		if err := parseRecipe(recipe, args[1:]); err != nil {
+			return fmt.Errorf("Invalid recipe %s: %w", recipeName, err)
+		}
+
+		if err := rootCmd.PersistentPreRunE(rootCmd, nil); err != nil {
+			return err
+		}
+		return rootCmd.RunE(rootCmd, nil)
+	},
+}
+
+var recipeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the recipes in cred's config file, and check that they are valid",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := loadSettings()
+		if err != nil {
+			return err
+		}
+
+		invalid := 0
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "RECIPE\tFLAGS\tRESULT")
+		for _, name := range slices.Sorted(maps.Keys(s.Recipes)) {
+			result := "ok"
+			if err := parseRecipe(s.Recipes[name], nil); err != nil {
+				result = err.Error()
+				invalid++
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", name, strings.Join(s.Recipes[name], " "), result)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if invalid > 0 {
+			return fmt.Errorf("%d of %d recipes are invalid", invalid, len(s.Recipes))
+		}
+		return nil
+	},
+}
+
+func init() {
+	recipeCmd.AddCommand(recipeListCmd)
+
+	rootCmd.AddCommand(recipeCmd)
+}
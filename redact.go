@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// stderr is where cred writes everything other than its output. Every secret
+// cred has resolved is replaced with **** on the way, so that no diagnostic,
+// warning or error can leak one into logs.
+var stderr = &redactor{w: os.Stderr}
+
+type redactor struct {
+	w       io.Writer
+	mu      sync.Mutex
+	secrets []string
+}
+
+// redact adds secrets to those replaced in everything written to stderr.
+func redact(secrets ...string) {
+	stderr.mu.Lock()
+	defer stderr.mu.Unlock()
+	for _, s := range secrets {
+		if s != "" {
+			stderr.secrets = append(stderr.secrets, s)
+		}
+	}
+}
+
+func (r *redactor) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := string(p)
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, "****")
+	}
+	if _, err := io.WriteString(r.w, s); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// redactingProvider adds the secrets of every set of credentials it retrieves
+// to those redacted from stderr, however they are used afterwards.
+type redactingProvider struct {
+	aws.CredentialsProvider
+}
+
+func (p redactingProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	creds, err := p.CredentialsProvider.Retrieve(ctx)
+	redact(creds.SecretAccessKey, creds.SessionToken)
+	return creds, err
+}
+
+// redactCredentials wraps provider so that its secrets are redacted from
+// stderr, keeping it cached.
+func redactCredentials(provider aws.CredentialsProvider) aws.CredentialsProvider {
+	if provider == nil {
+		return nil
+	}
+	return aws.NewCredentialsCache(redactingProvider{provider})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	var buf bytes.Buffer
+	r := &redactor{w: &buf}
+	r.secrets = []string{"s3cr3t", "t0k3n"}
+
+	n, err := r.Write([]byte("key s3cr3t and token t0k3n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len("key s3cr3t and token t0k3n\n") {
+		t.Errorf("Write returned %d, want the length of the input", n)
+	}
+	if got, want := buf.String(), "key **** and token ****\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// TestRedactDiagnostics runs each mode that writes diagnostics to stderr and
+// checks that none of the secrets cred resolved appear there.
+func TestRedactDiagnostics(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "spec.json")
+	writeTestFile(t, spec, `{"chain": [{"role_arn": "arn:aws:iam::123456789012:role/Hop", "session_name": "me"}]}`)
+
+	tests := []struct {
+		name    string
+		args    []string
+		invalid bool
+	}{
+		{name: "show-config", args: []string{"--show-config"}},
+		{name: "show-config json", args: []string{"--show-config=json"}},
+		{name: "show-chain", args: []string{"--show-chain", "--spec", spec}},
+		{name: "verify-chain", args: []string{"--verify-chain", "--spec", spec}},
+		{name: "print-policy-context", args: []string{"--print-policy-context", "--spec", spec}},
+		{name: "summary", args: []string{"--summary"}},
+		{name: "summary with spec", args: []string{"--summary", "--spec", spec}},
+		{name: "error", args: []string{"--spec", spec}, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeAWS(t)
+			if tt.invalid {
+				// An error that echoes the secrets back, as a badly behaved
+				// endpoint or a wrapped SDK error might.
+				f.handle["GetCallerIdentity"] = func(r *http.Request) (int, string) {
+					return http.StatusForbidden, fakeError("InvalidClientTokenId", "Rejected "+fakeSecret+" and "+fakeRoleToken)
+				}
+			}
+
+			out, err := runCred(t, f, tt.args...)
+			if tt.invalid != (err != nil) {
+				t.Fatalf("unexpected error %v, stderr:\n%s", err, out)
+			}
+			if out == "" {
+				t.Fatal("nothing was written to stderr")
+			}
+			for _, secret := range []string{fakeSecret, fakeRoleSecret, fakeRoleToken} {
+				if strings.Contains(out, secret) {
+					t.Errorf("stderr contains %q:\n%s", secret, out)
+				}
+			}
+		})
+	}
+}
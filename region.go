@@ -0,0 +1,237 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"golang.org/x/sync/errgroup"
+)
+
+// sourceProfileRegion returns the region of the nearest profile that sets one,
+// starting with the selected profile and following its source_profile chain.
+// The SDK only reads the region from the selected profile, so an assume-role
+// profile without its own region would otherwise resolve none.
+func sourceProfileRegion(cfg aws.Config) string {
+	for _, src := range cfg.ConfigSources {
+		shared, ok := src.(config.SharedConfig)
+		if !ok {
+			continue
+		}
+		for p := &shared; p != nil; p = p.Source {
+			if p.Region != "" {
+				return p.Region
+			}
+		}
+	}
+	return ""
+}
+
+var (
+	regionFlag   string
+	regionSets   []string
+	lockRegion   bool
+	verifyRegion bool
+	strictRegion bool
+)
+
+// startupRegionEnv is the region environment cred started with. cred clears
+// these variables before loading the config, so that its own exported values
+// never take precedence over the profile.
+var startupRegionEnv = []string{os.Getenv(region), os.Getenv(defaultRegion)}
+
+var (
+	regionSetLabel = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	regionPattern  = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+)
+
+// knownRegions are the AWS regions that region names without the trailing
+// number can expand to. Other names that look like regions are accepted
+// as they are, so that new regions work before they are listed here.
+var knownRegions = []string{
+	"af-south-1",
+	"ap-east-1", "ap-east-2",
+	"ap-northeast-1", "ap-northeast-2", "ap-northeast-3",
+	"ap-south-1", "ap-south-2",
+	"ap-southeast-1", "ap-southeast-2", "ap-southeast-3", "ap-southeast-4", "ap-southeast-5", "ap-southeast-6", "ap-southeast-7",
+	"ca-central-1", "ca-west-1",
+	"cn-north-1", "cn-northwest-1",
+	"eu-central-1", "eu-central-2",
+	"eu-north-1",
+	"eu-south-1", "eu-south-2",
+	"eu-west-1", "eu-west-2", "eu-west-3",
+	"il-central-1",
+	"me-central-1", "me-south-1",
+	"mx-central-1",
+	"sa-east-1",
+	"us-east-1", "us-east-2",
+	"us-gov-east-1", "us-gov-west-1",
+	"us-west-1", "us-west-2",
+}
+
+// regionSetVariables returns a LABEL_AWS_REGION variable for each
+// LABEL=region given with --region-set.
+func regionSetVariables() ([]variable, error) {
+	vars := []variable{}
+	for _, entry := range regionSets {
+		label, r, ok := strings.Cut(entry, "=")
+		if !ok || !regionSetLabel.MatchString(label) {
+			return nil, fmt.Errorf("Invalid --region-set %q: must be LABEL=region, e.g. USE1=us-east-1", entry)
+		}
+		r, err := expandRegion(r)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid --region-set %q: %w", entry, err)
+		}
+		vars = append(vars, set(label+"_"+region, r))
+	}
+	return vars, nil
+}
+
+// expandRegion returns the region that r names. Besides regions, it accepts
+// the aliases defined in cred's config file, and region names without the
+// trailing number, e.g. us-east for us-east-1.
+func expandRegion(r string) (string, error) {
+	if regionPattern.MatchString(r) {
+		return r, nil
+	}
+
+	s, err := loadSettings()
+	if err != nil {
+		return "", err
+	}
+
+	if alias, ok := s.RegionAliases[r]; ok {
+		if !regionPattern.MatchString(alias) {
+			return "", fmt.Errorf("Invalid region alias %s: %q is not a region", r, alias)
+		}
+		return alias, nil
+	}
+
+	if slices.Contains(knownRegions, r+"-1") {
+		return r + "-1", nil
+	}
+
+	known := "none are defined"
+	if len(s.RegionAliases) > 0 {
+		known = "known aliases are " + strings.Join(slices.Sorted(maps.Keys(s.RegionAliases)), ", ")
+	}
+	return "", fmt.Errorf("%q is not a region or region alias; %s", r, known)
+}
+
+// expandRegionFlags replaces region aliases given with --region and
+// --expect-region with the regions they name.
+func expandRegionFlags() error {
+	for _, flag := range []struct {
+		name  string
+		value *string
+	}{
+		{"--region", &regionFlag},
+		{"--expect-region", &expectRegion},
+	} {
+		if *flag.value == "" {
+			continue
+		}
+		r, err := expandRegion(*flag.value)
+		if err != nil {
+			return fmt.Errorf("Invalid %s: %w", flag.name, err)
+		}
+		*flag.value = r
+	}
+	return nil
+}
+
+// checkAllowedRegions fails if the profile's settings restrict it to a set of
+// regions, and any of regions is outside it.
+func checkAllowedRegions(regions []string) error {
+	s, err := loadSettings()
+	if err != nil {
+		return err
+	}
+
+	allowed := s.profile(profile).Regions
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	for _, r := range regions {
+		if r != "" && !slices.Contains(allowed, r) {
+			return fmt.Errorf("Region %s is not allowed for profile %s; allowed regions are %s", r, cmp.Or(profile, "default"), strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// warnUnverifiedRegions calls GetCallerIdentity on the STS endpoint of each of
+// regions concurrently, and warns about those where the credentials do not
+// work, e.g. because the region is not enabled for the account.
+func warnUnverifiedRegions(ctx context.Context, cfg aws.Config, regions []string) {
+	errs := make([]error, len(regions))
+
+	g := new(errgroup.Group)
+	for i, r := range regions {
+		g.Go(func() error {
+			_, errs[i] = sts.NewFromConfig(cfg, func(o *sts.Options) {
+				o.Region = r
+			}).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+			return nil
+		})
+	}
+	g.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			fmt.Fprintf(stderr, "Warning: the credentials do not work with STS in %s: %v\n", regions[i], err)
+		}
+	}
+}
+
+// checkStrictRegion fails with --strict-region if the places a region can
+// come from disagree, instead of silently picking one by precedence.
+func checkStrictRegion(cfg aws.Config) error {
+	if !strictRegion {
+		return nil
+	}
+
+	type source struct{ name, region string }
+	sources := []source{
+		{"--region", regionFlag},
+		{region, startupRegionEnv[0]},
+		{defaultRegion, startupRegionEnv[1]},
+	}
+	for _, src := range cfg.ConfigSources {
+		if shared, ok := src.(config.SharedConfig); ok {
+			sources = append(sources, source{"profile " + shared.Profile, shared.Region})
+			for p := shared.Source; p != nil && shared.Region == ""; p = p.Source {
+				if p.Region != "" {
+					sources = append(sources, source{"source profile " + p.Profile, p.Region})
+					break
+				}
+			}
+		}
+	}
+
+	found := []string{}
+	regions := []string{}
+	for _, src := range sources {
+		if src.region == "" {
+			continue
+		}
+		found = append(found, fmt.Sprintf("%s %s", src.name, src.region))
+		if !slices.Contains(regions, src.region) {
+			regions = append(regions, src.region)
+		}
+	}
+
+	if len(regions) > 1 {
+		return fmt.Errorf("The region sources disagree and --strict-region is set: %s", strings.Join(found, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// profileChain returns the shared config of profile "role", whose
+// source_profile is "source", whose own source_profile is "base".
+func profileChain(roleRegion, sourceRegion, baseRegion string) config.SharedConfig {
+	return config.SharedConfig{
+		Profile: "role",
+		Region:  roleRegion,
+		RoleARN: "arn:aws:iam::123456789012:role/Admin",
+		Source: &config.SharedConfig{
+			Profile: "source",
+			Region:  sourceRegion,
+			RoleARN: "arn:aws:iam::123456789012:role/Jump",
+			Source: &config.SharedConfig{
+				Profile: "base",
+				Region:  baseRegion,
+			},
+		},
+	}
+}
+
+func TestSourceProfileRegion(t *testing.T) {
+	tests := []struct {
+		name   string
+		shared config.SharedConfig
+		want   string
+	}{
+		{name: "role profile", shared: profileChain("eu-west-1", "us-east-2", ""), want: "eu-west-1"},
+		{name: "source profile", shared: profileChain("", "us-east-2", "ap-south-1"), want: "us-east-2"},
+		{name: "base profile", shared: profileChain("", "", "ap-south-1"), want: "ap-south-1"},
+		{name: "neither", shared: profileChain("", "", ""), want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := aws.Config{ConfigSources: []any{config.EnvConfig{}, tt.shared}}
+			if got := sourceProfileRegion(cfg); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("no shared config", func(t *testing.T) {
+		if got := sourceProfileRegion(aws.Config{}); got != "" {
+			t.Errorf("got %q, want no region", got)
+		}
+	})
+}
+
+func TestCheckStrictRegion(t *testing.T) {
+	tests := []struct {
+		name                  string
+		strict                bool
+		flag, env, defaultEnv string
+		shared                config.SharedConfig
+		wantErr               string
+	}{
+		{name: "not strict", flag: "us-east-1", env: "eu-west-1", shared: profileChain("ap-south-1", "", "")},
+		{name: "no region"},
+		{name: "one source", strict: true, shared: profileChain("eu-west-1", "", "")},
+		{name: "all agree", strict: true, flag: "eu-west-1", env: "eu-west-1", defaultEnv: "eu-west-1", shared: profileChain("eu-west-1", "", "")},
+		{
+			name:    "flag and profile",
+			strict:  true,
+			flag:    "us-east-1",
+			shared:  profileChain("eu-west-1", "", ""),
+			wantErr: "--region us-east-1, profile role eu-west-1",
+		},
+		{
+			name:       "environment variables",
+			strict:     true,
+			env:        "us-east-1",
+			defaultEnv: "us-west-2",
+			wantErr:    "AWS_REGION us-east-1, AWS_DEFAULT_REGION us-west-2",
+		},
+		{
+			name:    "environment and source profile",
+			strict:  true,
+			env:     "us-east-1",
+			shared:  profileChain("", "", "ap-south-1"),
+			wantErr: "AWS_REGION us-east-1, source profile base ap-south-1",
+		},
+		{
+			name:   "source profile is overridden by the profile",
+			strict: true,
+			flag:   "eu-west-1",
+			shared: profileChain("eu-west-1", "ap-south-1", ""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldStrict, oldFlag, oldEnv := strictRegion, regionFlag, startupRegionEnv
+			t.Cleanup(func() { strictRegion, regionFlag, startupRegionEnv = oldStrict, oldFlag, oldEnv })
+			strictRegion, regionFlag, startupRegionEnv = tt.strict, tt.flag, []string{tt.env, tt.defaultEnv}
+
+			err := checkStrictRegion(aws.Config{ConfigSources: []any{tt.shared}})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			want := "The region sources disagree and --strict-region is set: " + tt.wantErr
+			if err == nil || err.Error() != want {
+				t.Errorf("got error %v, want %s", err, want)
+			}
+		})
+	}
+}
+
+func TestExpandRegion(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "cred"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(dir, "cred", "config.json"), `{"region_aliases": {"prod": "eu-west-2", "broken": "nowhere"}}`)
+
+	tests := []struct {
+		region  string
+		want    string
+		wantErr bool
+	}{
+		{region: "us-east-1", want: "us-east-1"},
+		{region: "us-east", want: "us-east-1"},
+		{region: "eu-central", want: "eu-central-1"},
+		{region: "us-gov-west", want: "us-gov-west-1"},
+		{region: "prod", want: "eu-west-2"},
+		{region: "xx-future-3", want: "xx-future-3"},
+		{region: "zz-bogus", wantErr: true},
+		{region: "eu-west-9", want: "eu-west-9"},
+		{region: "ap-nowhere", wantErr: true},
+		{region: "broken", wantErr: true},
+		{region: "staging", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			got, err := expandRegion(tt.region)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
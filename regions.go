@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/account"
+	"github.com/aws/aws-sdk-go-v2/service/account/types"
+	"github.com/aws/smithy-go"
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/spf13/cobra"
+)
+
+var (
+	regionsOptedIn bool
+	regionsJSON    bool
+)
+
+type regionStatus struct {
+	Region string `json:"region"`
+	Status string `json:"status"`
+}
+
+var regionsCmd = &cobra.Command{
+	Use:   "regions",
+	Short: "List the regions enabled for the account",
+	Long:  wordwrap.WrapString("List the regions enabled for the account of the resolved credentials.\n\nThe regions are listed with account:ListRegions. Regions that are enabled by default are listed as ENABLED_BY_DEFAULT, and opt-in regions that have been enabled as ENABLED. Pass --opted-in to only list the latter.", 80),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		scope, err := readSessionScope(ctx)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig(ctx, scope)
+		if err != nil {
+			return err
+		}
+
+		client := account.NewFromConfig(cfg, func(o *account.Options) {
+			if o.Region == "" {
+				o.Region = "us-east-1"
+			}
+		})
+
+		statuses := []types.RegionOptStatus{types.RegionOptStatusEnabled}
+		if !regionsOptedIn {
+			statuses = append(statuses, types.RegionOptStatusEnabledByDefault)
+		}
+
+		regions := []regionStatus{}
+		pages := account.NewListRegionsPaginator(client, &account.ListRegionsInput{RegionOptStatusContains: statuses})
+		for pages.HasMorePages() {
+			page, err := pages.NextPage(ctx)
+			var apiErr smithy.APIError
+			if errors.As(err, &apiErr) && apiErr.ErrorCode() == "AccessDeniedException" {
+				return fmt.Errorf("The resolved credentials are not allowed to list the account's regions; they need account:ListRegions")
+			}
+			if err != nil {
+				return err
+			}
+			for _, r := range page.Regions {
+				regions = append(regions, regionStatus{Region: aws.ToString(r.RegionName), Status: string(r.RegionOptStatus)})
+			}
+		}
+
+		if regionsJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(regions)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "REGION\tSTATUS")
+		for _, r := range regions {
+			fmt.Fprintf(w, "%s\t%s\n", r.Region, r.Status)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	addCredentialFlags(regionsCmd)
+	regionsCmd.Flags().BoolVar(&regionsOptedIn, "opted-in", false, "Only list opt-in regions that have been enabled")
+	regionsCmd.Flags().BoolVar(&regionsJSON, "json", false, "Print the regions as JSON")
+
+	rootCmd.AddCommand(regionsCmd)
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// resolution is everything cred learns while resolving credentials, and the
+// variables it will set and unset as a result.
+type resolution struct {
+	cfg     aws.Config
+	creds   aws.Credentials
+	account string
+	alias   string
+	arn     string
+
+	exports []variable
+	unsets  []string
+
+	// unchanged is set when --output-only-if-changed found the credentials
+	// already exported, in which case nothing else is populated.
+	unchanged bool
+}
+
+// resolve fetches and validates the credentials selected by the credential
+// flags, runs --on-error-exec if that fails, and reports them with --summary
+// and --trace-out.
+func resolve(ctx context.Context) (*resolution, error) {
+	current := currentEnv()
+	start := time.Now()
+
+	// Every profile and retry is scoped the same way.
+	scope, err := readSessionScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resolveFallback(ctx, current, scope)
+	if err != nil && onErrorExec != "" {
+		res, err = recoverResolve(ctx, current, scope, err)
+	}
+	if traceOut != "" {
+		if traceErr := writeTrace(start, res, err); traceErr != nil {
+			fmt.Fprintf(stderr, "Warning: %s\n", traceErr)
+		}
+	}
+	if err == nil && printSummary && !res.unchanged {
+		fmt.Fprint(stderr, summaryLine(res))
+	}
+	return res, err
+}
+
+// resolveFallback resolves the current profile. With --profile-fallback, each
+// profile is tried in turn and the first one that resolves valid credentials
+// is used.
+func resolveFallback(ctx context.Context, current map[string]string, scope sessionScope) (*resolution, error) {
+	if len(profileFallback) == 0 {
+		return resolveProfile(ctx, current, scope)
+	}
+
+	errs := []error{}
+	for _, p := range profileFallback {
+		profile = p
+		res, err := resolveProfile(ctx, current, scope)
+		if err == nil {
+			fmt.Fprintf(stderr, "Using profile %s\n", p)
+			return res, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p, err))
+	}
+
+	return nil, fmt.Errorf("None of the fallback profiles resolved valid credentials:\n%w", errors.Join(errs...))
+}
+
+// resolveProfile resolves credentials for the current profile, scoped to
+// scope, given the values of cred's variables that were exported before cred
+// started.
+func resolveProfile(ctx context.Context, current map[string]string, scope sessionScope) (*resolution, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if deadline != "" {
+		t, err := time.Parse(time.RFC3339, deadline)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid --deadline %q: must be an RFC 3339 time, e.g. 2024-01-01T15:00:00Z", deadline)
+		}
+		if !now.Now().Before(t) {
+			return nil, fmt.Errorf("The --deadline %s has already passed", deadline)
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, t)
+		defer cancel()
+	}
+
+	regionVars, err := regionSetVariables()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateScopeNames(); err != nil {
+		return nil, err
+	}
+
+	cfg, err := loadConfig(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkRegion(cfg); err != nil {
+		return nil, err
+	}
+
+	exportedRegions := []string{cfg.Region}
+	for _, v := range regionVars {
+		exportedRegions = append(exportedRegions, v.Value)
+	}
+	if err := checkAllowedRegions(exportedRegions); err != nil {
+		return nil, err
+	}
+
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkTemporary(creds); err != nil {
+		return nil, err
+	}
+
+	// Roles and SSO have already been called by now, and usually return new
+	// credentials, so this mostly matches long-lived or cached credentials.
+	if onlyIfChanged && unchanged(current, creds) {
+		return &resolution{unchanged: true}, nil
+	}
+
+	data, alias, err := lookupIdentity(ctx, cfg, accountAlias)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkClockSkew(data); err != nil {
+		return nil, err
+	}
+
+	if err := checkScope(ctx, cfg); err != nil {
+		return nil, err
+	}
+
+	// GetCallerIdentity already ran in the resolved region.
+	if verifyRegion {
+		others := []string{}
+		for _, r := range exportedRegions[1:] {
+			if r != cfg.Region && !slices.Contains(others, r) {
+				others = append(others, r)
+			}
+		}
+		warnUnverifiedRegions(ctx, cfg, others)
+	}
+
+	account := creds.AccountID
+	if account == "" {
+		account = *data.Account
+	}
+
+	unsets := []string{}
+
+	exports := []variable{
+		set(accessKeyID, creds.AccessKeyID),
+		set(secretAccessKey, creds.SecretAccessKey),
+		set(accountID, account),
+	}
+
+	if alias != "" {
+		exports = append(exports, set(accountAliasVar, alias))
+	} else if accountAlias {
+		unsets = append(unsets, unset(accountAliasVar))
+	}
+
+	if cfg.Region != "" {
+		exports = append(exports, set(defaultRegion, cfg.Region))
+		exports = append(exports, set(region, cfg.Region))
+	} else if !lockRegion {
+		unsets = append(unsets, unset(defaultRegion))
+		unsets = append(unsets, unset(region))
+	}
+
+	exports = append(exports, regionVars...)
+
+	if creds.SessionToken != "" {
+		exports = append(
+			exports,
+			set(sessionToken, creds.SessionToken),
+			set(sessionExpiresAt, creds.Expires.Format(time.RFC3339)),
+		)
+	} else if creds.CanExpire {
+		// Long-lived keys can still expire, e.g. with the lease of a Vault
+		// secret.
+		unsets = append(unsets, unset(sessionToken))
+		exports = append(exports, set(sessionExpiresAt, creds.Expires.Format(time.RFC3339)))
+	} else {
+		unsets = append(
+			unsets,
+			unset(sessionToken),
+			unset(sessionExpiresAt),
+		)
+	}
+
+	if exportTTL && creds.CanExpire {
+		ttl := int(creds.Expires.Sub(now.Now()).Seconds())
+		exports = append(exports, set(sessionTTL, strconv.Itoa(max(ttl, 0))))
+	} else if exportTTL {
+		unsets = append(unsets, unset(sessionTTL))
+	}
+
+	if exportSessionID {
+		exports = append(exports, set(credSessionID, sessionID(creds)))
+	}
+
+	if legacyToken && creds.SessionToken != "" {
+		exports = append(exports, set(securityToken, creds.SessionToken))
+	} else if legacyToken {
+		unsets = append(unsets, unset(securityToken))
+	}
+
+	if cleanUnresolved {
+		unsets = append(unsets, unresolved(exports, unsets)...)
+	}
+
+	exports, unsets = withoutContextVars(exports, unsets)
+
+	return &resolution{
+		cfg:     cfg,
+		creds:   creds,
+		account: account,
+		alias:   alias,
+		arn:     aws.ToString(data.Arn),
+		exports: exports,
+		unsets:  unsets,
+	}, nil
+}
+
+// sessionID derives an ID for the session from its access key ID and expiry,
+// neither of which is secret, so that it stays the same for as long as the
+// session lasts. The ID cannot be reversed to the access key ID.
+func sessionID(creds aws.Credentials) string {
+	sum := sha256.Sum256([]byte(creds.AccessKeyID + "|" + creds.Expires.UTC().Format(time.RFC3339)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// unresolved returns the names of the variables that cred manages but is
+// neither setting nor already unsetting, so --clean-unresolved can unset them.
+// With --lock-region, the region variables are left alone.
+func unresolved(exports []variable, unsets []string) []string {
+	handled := slices.Clone(unsets)
+	for _, v := range exports {
+		handled = append(handled, v.Name)
+	}
+	if lockRegion {
+		handled = append(handled, name(region), name(defaultRegion))
+	}
+
+	names := []string{}
+	for _, key := range allVars() {
+		if !slices.Contains(handled, name(key)) {
+			names = append(names, unset(key))
+		}
+	}
+	return names
+}
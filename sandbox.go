@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/spf13/cobra"
+)
+
+var sandboxDir string
+
+// sandboxFiles returns the config and credentials files of the sandbox in
+// dir.
+func sandboxFiles(dir string) (string, string) {
+	return filepath.Join(dir, ".aws", "config"), filepath.Join(dir, ".aws", "credentials")
+}
+
+// removeSandbox deletes the files cred wrote to the sandbox in dir, and its
+// .aws directory if nothing else is left in it.
+func removeSandbox(dir string) error {
+	configFile, credsFile := sandboxFiles(dir)
+	for _, path := range []string{configFile, credsFile} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("Failed to remove sandbox: %w", err)
+		}
+	}
+
+	if err := os.Remove(filepath.Dir(configFile)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(stderr, "Warning: left %s in place: %v\n", filepath.Dir(configFile), err)
+	}
+	fmt.Fprintf(stderr, "Removed sandbox %s\n", dir)
+	return nil
+}
+
+var sandboxCmd = &cobra.Command{
+	Use:   "sandbox",
+	Short: "Write credentials to a self-contained AWS config under a directory",
+	Long:  wordwrap.WrapString("Write credentials to a self-contained AWS config under a directory.\n\nThe credentials and region are written as the default profile of .aws/credentials and .aws/config under --dir, and the output points AWS_CONFIG_FILE and AWS_SHARED_CREDENTIALS_FILE at them, e.g. eval $(cred sandbox --dir /tmp/sandbox). Your own ~/.aws is never touched, which keeps subprocesses and test runs hermetic. Remove sandboxes with `cred sandbox clean`.", 80),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := filepath.Abs(sandboxDir)
+		if err != nil {
+			return err
+		}
+
+		s, err := loadState()
+		if err != nil {
+			return err
+		}
+
+		configFile, credsFile := sandboxFiles(dir)
+		if !slices.Contains(s.Sandboxes, dir) {
+			for _, path := range []string{configFile, credsFile} {
+				if _, err := os.Stat(path); err == nil {
+					return fmt.Errorf("%s already exists and was not created by cred", path)
+				}
+			}
+		}
+
+		res, err := resolve(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(configFile), 0o700); err != nil {
+			return fmt.Errorf("Failed to write sandbox: %w", err)
+		}
+
+		values := []variable{}
+		if res.cfg.Region != "" {
+			values = append(values, variable{Name: "region", Value: res.cfg.Region})
+		}
+		if err := writeFileAtomic(configFile, []byte(setSection("", "default", values))); err != nil {
+			return fmt.Errorf("Failed to write sandbox config file: %w", err)
+		}
+		if err := writeFileAtomic(credsFile, []byte(setSection("", "default", profileValues(res.creds)))); err != nil {
+			return fmt.Errorf("Failed to write sandbox credentials file: %w", err)
+		}
+
+		if !slices.Contains(s.Sandboxes, dir) {
+			s.Sandboxes = append(s.Sandboxes, dir)
+			if err := s.save(); err != nil {
+				return err
+			}
+		}
+
+		// Variables take precedence over the files, so any that are set
+		// would escape the sandbox.
+		fmt.Print(script(
+			[]variable{{Name: "AWS_CONFIG_FILE", Value: configFile}, {Name: "AWS_SHARED_CREDENTIALS_FILE", Value: credsFile}},
+			[]string{"AWS_PROFILE", accessKeyID, secretAccessKey, sessionToken, region, defaultRegion},
+		))
+		return nil
+	},
+}
+
+var sandboxCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove sandboxes written by cred",
+	Long:  wordwrap.WrapString("Remove sandboxes written by cred.\n\nWith --dir, only that sandbox is removed. Otherwise every sandbox that cred has written is.", 80),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := loadState()
+		if err != nil {
+			return err
+		}
+
+		only := ""
+		if sandboxDir != "" {
+			if only, err = filepath.Abs(sandboxDir); err != nil {
+				return err
+			}
+			if !slices.Contains(s.Sandboxes, only) {
+				return fmt.Errorf("%s is not a sandbox created by cred", only)
+			}
+		}
+
+		kept := []string{}
+		for _, dir := range s.Sandboxes {
+			if only != "" && dir != only {
+				kept = append(kept, dir)
+				continue
+			}
+			if err := removeSandbox(dir); err != nil {
+				return err
+			}
+		}
+
+		s.Sandboxes = kept
+		return s.save()
+	},
+}
+
+func init() {
+	addCredentialFlags(sandboxCmd)
+	sandboxCmd.Flags().StringVar(&sandboxDir, "dir", "", "Directory to write the sandbox's .aws directory to")
+	sandboxCmd.MarkFlagRequired("dir")
+
+	sandboxCleanCmd.Flags().StringVar(&sandboxDir, "dir", "", "Only remove the sandbox in this directory")
+
+	sandboxCmd.AddCommand(sandboxCleanCmd)
+	rootCmd.AddCommand(sandboxCmd)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// credentialProcessSchema describes processCredentials as cred marshals it.
+const credentialProcessSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "credential_process output",
+  "type": "object",
+  "properties": {
+    "Version": {
+      "const": 1
+    },
+    "AccessKeyId": {
+      "type": "string",
+      "minLength": 1
+    },
+    "SecretAccessKey": {
+      "type": "string",
+      "minLength": 1
+    },
+    "SessionToken": {
+      "type": "string",
+      "minLength": 1
+    },
+    "Expiration": {
+      "type": "string",
+      "format": "date-time"
+    }
+  },
+  "required": ["Version", "AccessKeyId", "SecretAccessKey"],
+  "additionalProperties": false
+}
+`
+
+var schemas = map[string]string{
+	"credential-process": credentialProcessSchema,
+}
+
+var schemaCmd = &cobra.Command{
+	Use:       "schema credential-process",
+	Short:     "Print the JSON Schema of a document that cred produces",
+	Hidden:    true,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"credential-process"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Print(schemas[args[0]])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"golang.org/x/sync/errgroup"
+)
+
+var validateScope []string
+
+// scopeProbes are the permissions that --validate-scope can check. Each probe
+// makes one read-only call that needs the permission, asking for as little
+// data as possible.
+var scopeProbes = map[string]func(ctx context.Context, cfg aws.Config) error{
+	"iam:GetAccountSummary": func(ctx context.Context, cfg aws.Config) error {
+		_, err := iam.NewFromConfig(cfg, iamRegion).GetAccountSummary(ctx, &iam.GetAccountSummaryInput{})
+		return err
+	},
+	"iam:ListAccountAliases": func(ctx context.Context, cfg aws.Config) error {
+		_, err := iam.NewFromConfig(cfg, iamRegion).ListAccountAliases(ctx, &iam.ListAccountAliasesInput{MaxItems: aws.Int32(1)})
+		return err
+	},
+	"s3:ListAllMyBuckets": func(ctx context.Context, cfg aws.Config) error {
+		_, err := s3.NewFromConfig(cfg, func(o *s3.Options) {
+			if o.Region == "" {
+				o.Region = "us-east-1"
+			}
+		}).ListBuckets(ctx, &s3.ListBucketsInput{MaxBuckets: aws.Int32(1)})
+		return err
+	},
+}
+
+func scopeProbeNames() []string {
+	names := []string{}
+	for name := range scopeProbes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// validateScopeNames fails if --validate-scope names a permission that cred
+// has no probe for.
+func validateScopeNames() error {
+	for _, action := range validateScope {
+		if _, ok := scopeProbes[action]; !ok {
+			return fmt.Errorf("Invalid --validate-scope %q: must be one of %s", action, strings.Join(scopeProbeNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// checkScope runs the probes requested with --validate-scope concurrently, and
+// fails if the credentials cannot perform any of them.
+func checkScope(ctx context.Context, cfg aws.Config) error {
+	errs := make([]error, len(validateScope))
+
+	g := new(errgroup.Group)
+	for i, action := range validateScope {
+		g.Go(func() error {
+			err := scopeProbes[action](ctx, cfg)
+			var apiErr smithy.APIError
+			if errors.As(err, &apiErr) {
+				errs[i] = fmt.Errorf("The resolved credentials cannot perform %s: %s: %s", action, apiErr.ErrorCode(), apiErr.ErrorMessage())
+			} else if err != nil {
+				errs[i] = fmt.Errorf("Failed to check %s: %w", action, err)
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// settings is cred's own configuration, which the user maintains by hand.
+type settings struct {
+	Profiles map[string]profileSettings `json:"profiles,omitempty"`
+
+	// RegionAliases map names that can be used instead of a region, wherever
+	// cred accepts one, to the region they stand for.
+	RegionAliases map[string]string `json:"region_aliases,omitempty"`
+
+	// AccountNames map account IDs to friendly names, which cred shows next
+	// to the IDs.
+	AccountNames map[string]string `json:"account_names,omitempty"`
+
+	// Recipes are named lists of flags, run with `cred recipe NAME`.
+	Recipes map[string][]string `json:"recipes,omitempty"`
+}
+
+// profileSettings is cred's configuration for one AWS profile.
+type profileSettings struct {
+	// Regions, if set, are the only regions cred will export for the profile.
+	Regions []string `json:"regions,omitempty"`
+}
+
+func settingsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("Failed to locate cred's config file: %w", err)
+	}
+	return filepath.Join(dir, "cred", "config.json"), nil
+}
+
+// loadSettings reads cred's config file. A missing file is empty settings.
+func loadSettings() (*settings, error) {
+	path, err := settingsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &settings{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read cred's config file: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var s settings
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("Invalid config file %s: %w", path, err)
+	}
+
+	return &s, nil
+}
+
+// profile returns the settings for the named profile, which are empty if the
+// config file does not mention it.
+func (s *settings) profile(name string) profileSettings {
+	if name == "" {
+		name = "default"
+	}
+	return s.Profiles[name]
+}
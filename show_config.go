@@ -0,0 +1,80 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+var showConfig string
+
+// resolvedConfig lists everything that influences how cred resolves and
+// prints credentials for cfg, in the order --show-config prints it.
+func resolvedConfig(cfg aws.Config) [][2]string {
+	timeoutValue := "none"
+	if timeout > 0 {
+		timeoutValue = timeout.String()
+	}
+
+	output := format
+	if formatTemplate != "" {
+		output = "template " + formatTemplate
+	}
+
+	fields := [][2]string{
+		{"profile", cmp.Or(profile, "default")},
+		{"region", cmp.Or(cfg.Region, "none")},
+		{"source", credentialKind(cfg)},
+	}
+	for _, src := range cfg.ConfigSources {
+		if shared, ok := src.(config.SharedConfig); ok && shared.Credentials.AccessKeyID != "" {
+			fields = append(fields, [2]string{"access_key_id", mask(accessKeyID, shared.Credentials.AccessKeyID)})
+		}
+	}
+
+	return append(fields, [][2]string{
+		{"endpoint_url", cmp.Or(endpointURL, "default")},
+		{"insecure_skip_verify", strconv.FormatBool(insecureSkipVerify)},
+		{"min_tls_version", cmp.Or(minTLSVersion, "default")},
+		{"retry_mode", cmp.Or(string(cfg.RetryMode), string(aws.RetryModeStandard))},
+		{"retry_max_attempts", strconv.Itoa(cmp.Or(cfg.RetryMaxAttempts, retry.DefaultMaxAttempts))},
+		{"timeout", timeoutValue},
+		{"deadline", cmp.Or(deadline, "none")},
+		{"spec", cmp.Or(specFile, "none")},
+		{"config_file", configFilePath()},
+		{"credentials_file", cmp.Or(credentialsFile, credentialsFilePath())},
+		{"prefix", cmp.Or(prefix, "none")},
+		{"format", output},
+	}...)
+}
+
+// printResolvedConfig writes the resolved configuration to w as key: value
+// lines, or as a JSON object with --show-config=json.
+func printResolvedConfig(w io.Writer, cfg aws.Config) error {
+	fields := resolvedConfig(cfg)
+
+	switch showConfig {
+	case "text":
+		fmt.Fprintln(w, "Resolved config:")
+		for _, f := range fields {
+			fmt.Fprintf(w, "  %s: %s\n", f[0], f[1])
+		}
+		return nil
+	case "json":
+		doc := map[string]string{}
+		for _, f := range fields {
+			doc[f[0]] = f[1]
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	default:
+		return fmt.Errorf("Invalid --show-config %q: must be text or json", showConfig)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os/user"
+	"strings"
+)
+
+var (
+	sourceIdentityFlag     string
+	sourceIdentityFromUser bool
+)
+
+// sourceIdentity returns the SourceIdentity to set when assuming roles, or an
+// empty string if none was requested.
+func sourceIdentity() (string, error) {
+	id := sourceIdentityFlag
+	if sourceIdentityFromUser {
+		u, err := user.Current()
+		if err != nil {
+			return "", fmt.Errorf("Failed to look up the local username for --source-identity-from-user: %w", err)
+		}
+		// Windows usernames include the domain, e.g. CORP\alice.
+		id = u.Username[strings.LastIndex(u.Username, `\`)+1:]
+	}
+
+	if id == "" {
+		return "", nil
+	}
+
+	if !sessionNamePattern.MatchString(id) || strings.HasPrefix(strings.ToLower(id), "aws:") {
+		return "", fmt.Errorf("Invalid source identity %q: must be 2-64 characters of letters, digits and +=,.@_-, and must not start with aws:", id)
+	}
+
+	return id, nil
+}
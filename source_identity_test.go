@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceIdentityFlags(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "spec.json")
+	writeTestFile(t, spec, `{"chain": [{"role_arn": "arn:aws:iam::123456789012:role/Hop", "session_name": "me"}]}`)
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "none", args: nil, want: ""},
+		{name: "value", args: []string{"--source-identity", "alice"}, want: "alice"},
+		{name: "value with =", args: []string{"--source-identity=alice"}, want: "alice"},
+		{name: "invalid", args: []string{"--source-identity", "aws:alice"}, wantErr: true},
+		{name: "both", args: []string{"--source-identity", "alice", "--source-identity-from-user"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeAWS(t)
+			got := ""
+			f.handle["AssumeRole"] = func(r *http.Request) (int, string) {
+				got = r.Form.Get("SourceIdentity")
+				return http.StatusOK, fakeResponses["AssumeRole"]
+			}
+
+			out, err := runCred(t, f, append([]string{"--spec", spec}, tt.args...)...)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v, stderr:\n%s", err, out)
+			}
+			if got != tt.want {
+				t.Errorf("got SourceIdentity %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
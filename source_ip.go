@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+var (
+	scopeToIP   []string
+	scopeToMyIP bool
+)
+
+// checkIPURL reports the caller's public IP address, as seen by AWS.
+const checkIPURL = "https://checkip.amazonaws.com"
+
+// sourceCIDRs returns the CIDR blocks given with --scope-to-ip, and the public
+// IP address of this host with --scope-to-my-ip. Bare addresses are turned
+// into single-address blocks.
+func sourceCIDRs(ctx context.Context) ([]string, error) {
+	entries := scopeToIP
+	if scopeToMyIP {
+		ip, err := myIP(ctx)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ip)
+	}
+
+	cidrs := []string{}
+	for _, entry := range entries {
+		if _, block, err := net.ParseCIDR(entry); err == nil {
+			cidrs = append(cidrs, block.String())
+			continue
+		}
+
+		ip := net.ParseIP(entry)
+		switch {
+		case ip == nil:
+			return nil, fmt.Errorf("Invalid --scope-to-ip %q: must be an IP address or CIDR block", entry)
+		case ip.To4() != nil:
+			cidrs = append(cidrs, ip.String()+"/32")
+		default:
+			cidrs = append(cidrs, ip.String()+"/128")
+		}
+	}
+	return cidrs, nil
+}
+
+func myIP(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, checkIPURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Failed to look up this host's IP address: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Failed to look up this host's IP address from %s", checkIPURL)
+	}
+
+	ip := strings.TrimSpace(string(body))
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("Failed to look up this host's IP address: %s returned %q", checkIPURL, ip)
+	}
+	return ip, nil
+}
+
+// sourceIPStatement denies every request from outside cidrs. Requests that AWS
+// services make on the session's behalf come from AWS's own addresses, so
+// they are exempt.
+func sourceIPStatement(cidrs []string) map[string]any {
+	return map[string]any{
+		"Sid":      "CredScopeToIP",
+		"Effect":   "Deny",
+		"Action":   "*",
+		"Resource": "*",
+		"Condition": map[string]any{
+			"NotIpAddress": map[string]any{"aws:SourceIp": cidrs},
+			"Bool":         map[string]any{"aws:ViaAWSService": "false"},
+		},
+	}
+}
+
+// scopePolicy adds the sourceIPStatement for cidrs to the session policy. A
+// session policy limits the session to what it allows, so without one, the
+// statement is paired with one that allows everything the role can do.
+func scopePolicy(policy json.RawMessage, cidrs []string) (json.RawMessage, error) {
+	doc := map[string]any{
+		"Version":   "2012-10-17",
+		"Statement": []any{map[string]any{"Effect": "Allow", "Action": "*", "Resource": "*"}},
+	}
+	if len(policy) > 0 {
+		if err := json.Unmarshal(policy, &doc); err != nil {
+			return nil, err
+		}
+	}
+
+	statements, ok := doc["Statement"].([]any)
+	if !ok {
+		statements = []any{doc["Statement"]}
+	}
+	doc["Statement"] = append(statements, sourceIPStatement(cidrs))
+
+	return json.Marshal(doc)
+}
+
+// checkSourceIPProfile fails unless the profile assumes exactly one role, the
+// only one the scoped session policy can be attached to without also
+// restricting where the roles before it can be assumed from.
+func checkSourceIPProfile(cfg aws.Config) error {
+	roles := 0
+	for _, src := range cfg.ConfigSources {
+		if shared, ok := src.(config.SharedConfig); ok {
+			for p := &shared; p != nil; p = p.Source {
+				if p.RoleARN != "" {
+					roles++
+				}
+			}
+		}
+	}
+
+	if roles != 1 {
+		return fmt.Errorf("--scope-to-ip and --scope-to-my-ip need --spec, or a profile that assumes exactly one role")
+	}
+	return nil
+}
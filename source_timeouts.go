@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/processcreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+)
+
+var (
+	imdsTimeout    time.Duration
+	ssoTimeout     time.Duration
+	processTimeout time.Duration
+)
+
+// withSourceTimeout calls fn with ctx limited to d, unless d is zero, and
+// names the flag that set d if it ran out.
+func withSourceTimeout[T any](ctx context.Context, d time.Duration, flag string, fn func(context.Context) (T, error)) (T, error) {
+	if d <= 0 {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	v, err := fn(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return v, fmt.Errorf("Gave up after %s, set with --%s: %w", d, flag, err)
+	}
+	return v, err
+}
+
+// ssoTimeoutClient limits each call for SSO role credentials to --sso-timeout.
+type ssoTimeoutClient struct {
+	ssocreds.GetRoleCredentialsAPIClient
+}
+
+func (c ssoTimeoutClient) GetRoleCredentials(ctx context.Context, in *sso.GetRoleCredentialsInput, optFns ...func(*sso.Options)) (*sso.GetRoleCredentialsOutput, error) {
+	return withSourceTimeout(ctx, ssoTimeout, "sso-timeout", func(ctx context.Context) (*sso.GetRoleCredentialsOutput, error) {
+		return c.GetRoleCredentialsAPIClient.GetRoleCredentials(ctx, in, optFns...)
+	})
+}
+
+// imdsTimeoutClient limits each call to IMDS for instance role credentials to
+// --imds-timeout. IMDS is only called once the config is loaded, so that the
+// client can be made from it as the SDK would.
+type imdsTimeoutClient struct {
+	cfg    *aws.Config
+	once   sync.Once
+	client *imds.Client
+}
+
+func (c *imdsTimeoutClient) GetMetadata(ctx context.Context, in *imds.GetMetadataInput, optFns ...func(*imds.Options)) (*imds.GetMetadataOutput, error) {
+	c.once.Do(func() { c.client = imds.NewFromConfig(*c.cfg) })
+	return withSourceTimeout(ctx, imdsTimeout, "imds-timeout", func(ctx context.Context) (*imds.GetMetadataOutput, error) {
+		return c.client.GetMetadata(ctx, in, optFns...)
+	})
+}
+
+// sourceTimeoutOptions returns load options that limit how long each kind of
+// credential source may take, wherever it appears in the profile's chain. The
+// IMDS client is made from cfg, which must be set to the loaded config.
+func sourceTimeoutOptions(cfg *aws.Config) []func(*config.LoadOptions) error {
+	opts := []func(*config.LoadOptions) error{}
+
+	if imdsTimeout > 0 {
+		opts = append(opts, config.WithEC2RoleCredentialOptions(func(o *ec2rolecreds.Options) {
+			if o.Client == nil {
+				o.Client = &imdsTimeoutClient{cfg: cfg}
+			}
+		}))
+	}
+
+	if ssoTimeout > 0 {
+		opts = append(opts, config.WithSSOProviderOptions(func(o *ssocreds.Options) {
+			o.Client = ssoTimeoutClient{o.Client}
+		}))
+	}
+
+	if processTimeout > 0 {
+		opts = append(opts, config.WithProcessCredentialOptions(func(o *processcreds.Options) {
+			o.Timeout = processTimeout
+		}))
+	}
+
+	return opts
+}
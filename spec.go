@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+// spec describes a chain of roles to assume, read from a --spec file.
+type spec struct {
+	Chain []hop `json:"chain"`
+}
+
+// hop is a single AssumeRole call in a spec's chain.
+type hop struct {
+	RoleARN     string            `json:"role_arn"`
+	SessionName string            `json:"session_name,omitempty"`
+	ExternalID  string            `json:"external_id,omitempty"`
+	Duration    string            `json:"duration,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Policy      json.RawMessage   `json:"policy,omitempty"`
+	PolicyARNs  []string          `json:"policy_arns,omitempty"`
+	MFASerial   string            `json:"mfa_serial,omitempty"`
+
+	duration time.Duration
+}
+
+var sessionNamePattern = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+
+// loadSpec reads and validates the spec file at path.
+func loadSpec(path string) (*spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read spec: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var s spec
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("Invalid spec %s: %w", path, err)
+	}
+
+	if len(s.Chain) == 0 {
+		return nil, fmt.Errorf("Invalid spec %s: chain must contain at least one role", path)
+	}
+
+	for i := range s.Chain {
+		if err := s.Chain[i].validate(); err != nil {
+			return nil, fmt.Errorf("Invalid spec %s: chain[%d]: %w", path, i, err)
+		}
+	}
+
+	return &s, nil
+}
+
+func (h *hop) validate() error {
+	if h.RoleARN == "" {
+		return errors.New("role_arn is required")
+	}
+
+	parsed, err := arn.Parse(h.RoleARN)
+	if err != nil || parsed.Service != "iam" || !strings.HasPrefix(parsed.Resource, "role/") {
+		return fmt.Errorf("role_arn %q is not an IAM role ARN", h.RoleARN)
+	}
+
+	if h.SessionName != "" && !sessionNamePattern.MatchString(h.SessionName) {
+		return fmt.Errorf("session_name %q must be 2-64 characters of letters, digits and +=,.@_-", h.SessionName)
+	}
+
+	if h.Duration != "" {
+		d, err := time.ParseDuration(h.Duration)
+		if err != nil {
+			return fmt.Errorf("duration %q is not a valid duration, e.g. 1h", h.Duration)
+		}
+		if d < 15*time.Minute || d > 12*time.Hour {
+			return fmt.Errorf("duration %q must be between 15m and 12h", h.Duration)
+		}
+		h.duration = d
+	}
+
+	for key, val := range h.Tags {
+		if key == "" || len(key) > 128 {
+			return fmt.Errorf("tag key %q must be 1-128 characters", key)
+		}
+		if len(val) > 256 {
+			return fmt.Errorf("tag %q value must be at most 256 characters", key)
+		}
+	}
+
+	if len(h.Policy) > 0 {
+		var doc map[string]any
+		if err := json.Unmarshal(h.Policy, &doc); err != nil {
+			return errors.New("policy must be a JSON object")
+		}
+	}
+
+	for _, policyARN := range h.PolicyARNs {
+		if _, err := arn.Parse(policyARN); err != nil {
+			return fmt.Errorf("policy_arns entry %q is not an ARN", policyARN)
+		}
+	}
+
+	return nil
+}
+
+// assume returns a copy of cfg whose credentials are the result of assuming
+// each role in the chain in turn, starting from cfg's own credentials. A
+// non-empty sourceID is set as the SourceIdentity of every session. With
+// --verify-chain, the credentials of every role are checked as it is assumed.
+func (s *spec) assume(ctx context.Context, cfg aws.Config, sourceID string) (aws.Config, error) {
+	for i, h := range s.Chain {
+		if h.duration == 0 && maxDurationAuto {
+			h.duration = maxSessionDuration(ctx, cfg, h.RoleARN, i > 0)
+		}
+
+		newProvider := func(d time.Duration) aws.CredentialsProvider {
+			return stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), h.RoleARN, h.options, func(o *stscreds.AssumeRoleOptions) {
+				o.Duration = d
+				if sourceID != "" {
+					o.SourceIdentity = aws.String(sourceID)
+				}
+			})
+		}
+
+		// Probing is skipped for chained roles, which AWS limits to one hour,
+		// and for roles that need MFA, whose codes cannot be reused.
+		var provider aws.CredentialsProvider
+		if h.duration == 0 && durationProbe && i == 0 && h.MFASerial == "" {
+			d, creds, err := probeDuration(ctx, h.RoleARN, newProvider)
+			if err != nil {
+				return aws.Config{}, err
+			}
+			provider = &probedProvider{creds: creds, CredentialsProvider: newProvider(d)}
+		} else {
+			provider = newProvider(h.duration)
+		}
+
+		cfg = cfg.Copy()
+		cfg.Credentials = redactCredentials(provider)
+
+		if verifyChain {
+			if err := verifyStep(ctx, cfg, fmt.Sprintf("chain[%d]", i)); err != nil {
+				return aws.Config{}, err
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// maxSessionDuration returns the longest session that can be requested for
+// the role, or zero to use the default if the role cannot be read. Sessions
+// for roles assumed by role chaining are limited to one hour by AWS, so
+// there is nothing to look up for them. That includes the first role when
+// cfg's credentials are already a role session, e.g. from an assume-role
+// profile or SSO.
+func maxSessionDuration(ctx context.Context, cfg aws.Config, roleARN string, chained bool) time.Duration {
+	if chained {
+		return time.Hour
+	}
+
+	parsed, err := arn.Parse(roleARN)
+	if err != nil {
+		return 0
+	}
+	roleName := parsed.Resource[strings.LastIndex(parsed.Resource, "/")+1:]
+
+	caller, err := getCallerIdentity(ctx, cfg)
+	if err != nil {
+		return 0
+	}
+	if callerARN, err := arn.Parse(aws.ToString(caller.Arn)); err == nil && strings.HasPrefix(callerARN.Resource, "assumed-role/") {
+		return time.Hour
+	}
+
+	// GetRole can only read roles in the caller's own account, and would
+	// find a different role there with the same name.
+	if parsed.AccountID != aws.ToString(caller.Account) {
+		return 0
+	}
+
+	client := iam.NewFromConfig(cfg, iamRegion)
+
+	data, err := client.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(roleName)})
+	if err != nil || data.Role.MaxSessionDuration == nil {
+		return 0
+	}
+
+	return time.Duration(*data.Role.MaxSessionDuration) * time.Second
+}
+
+func (h hop) options(o *stscreds.AssumeRoleOptions) {
+	o.RoleSessionName = h.SessionName
+	o.Duration = h.duration
+
+	if h.ExternalID != "" {
+		o.ExternalID = aws.String(h.ExternalID)
+	}
+
+	if h.MFASerial != "" {
+		o.SerialNumber = aws.String(h.MFASerial)
+		o.TokenProvider = mfaTokenProvider
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(h.Tags)) {
+		o.Tags = append(o.Tags, types.Tag{Key: aws.String(key), Value: aws.String(h.Tags[key])})
+	}
+
+	if len(h.Policy) > 0 {
+		o.Policy = aws.String(string(h.Policy))
+	}
+
+	for _, policyARN := range h.PolicyARNs {
+		o.PolicyARNs = append(o.PolicyARNs, types.PolicyDescriptorType{Arn: aws.String(policyARN)})
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaxSessionDuration(t *testing.T) {
+	assumedRole := strings.Replace(fakeResponses["GetCallerIdentity"], fakeCallerARN, "arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_Admin/alice", 1)
+
+	tests := []struct {
+		name        string
+		roleARN     string
+		chained     bool
+		caller      string
+		getRole     func(r *http.Request) (int, string)
+		want        time.Duration
+		wantGetRole bool
+	}{
+		{name: "same account", roleARN: "arn:aws:iam::123456789012:role/Admin", want: 12 * time.Hour, wantGetRole: true},
+		{name: "other account", roleARN: "arn:aws:iam::210987654321:role/Admin", want: 0},
+		{name: "chained", roleARN: "arn:aws:iam::123456789012:role/Admin", chained: true, want: time.Hour},
+		{name: "caller is a role session", roleARN: "arn:aws:iam::123456789012:role/Admin", caller: assumedRole, want: time.Hour},
+		{
+			name:    "access denied",
+			roleARN: "arn:aws:iam::123456789012:role/Admin",
+			getRole: func(r *http.Request) (int, string) {
+				return http.StatusForbidden, fakeError("AccessDenied", "Not allowed to get the role")
+			},
+			want:        0,
+			wantGetRole: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeAWS(t)
+			if tt.caller != "" {
+				f.handle["GetCallerIdentity"] = func(r *http.Request) (int, string) { return http.StatusOK, tt.caller }
+			}
+			if tt.getRole != nil {
+				f.handle["GetRole"] = tt.getRole
+			}
+
+			if got := maxSessionDuration(context.Background(), f.config(), tt.roleARN, tt.chained); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			if got := f.called("GetRole") > 0; got != tt.wantGetRole {
+				t.Errorf("GetRole called: got %t, want %t", got, tt.wantGetRole)
+			}
+		})
+	}
+}
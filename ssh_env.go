@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/spf13/cobra"
+)
+
+// sshEnv renders the standard AWS variables among exports as inline
+// assignments, for running a remote command with `ssh host env ...`.
+func sshEnv(exports []variable) string {
+	assignments := []string{}
+	for _, v := range exports {
+		if slices.Contains(allVars(), v.key) {
+			assignments = append(assignments, fmt.Sprintf("%s=%s", v.key, v.Value))
+		}
+	}
+	return strings.Join(assignments, " ") + "\n"
+}
+
+var sshEnvCmd = &cobra.Command{
+	Use:   "ssh-env",
+	Short: "Print AWS credentials as inline assignments for a remote command",
+	Long:  wordwrap.WrapString("Print AWS credentials as inline assignments for a remote command, e.g. ssh host env $(cred ssh-env) aws s3 ls.\n\nOnly the standard AWS variables are included, without any --prefix. The assignments are part of the remote command line, so other users of the remote host can see the secrets in its process list while the command runs.", 80),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res, err := resolve(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(stderr, "Warning: the remote command line contains secrets, which other users of the remote host can see in its process list")
+		fmt.Print(sshEnv(res.exports))
+		return nil
+	},
+}
+
+func init() {
+	addCredentialFlags(sshEnvCmd)
+	sshEnvCmd.Flags().BoolVar(&printSummary, "summary", false, "Print a one-line summary of the resolved credentials to stderr, without secrets")
+	sshEnvCmd.Flags().StringVar(&traceOut, "trace-out", "", "Write a JSON timing summary of resolving the credentials and each AWS call to this file, without secrets")
+
+	rootCmd.AddCommand(sshEnvCmd)
+}
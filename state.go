@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// state is what cred remembers between invocations.
+type state struct {
+	TempProfiles []tempProfile `json:"temp_profiles,omitempty"`
+
+	// Sandboxes are the directories that `cred sandbox` wrote to.
+	Sandboxes []string `json:"sandboxes,omitempty"`
+
+	// LastFormat is the --format most recently used to export credentials.
+	LastFormat string `json:"last_format,omitempty"`
+
+	// RoleMaxDurations are the maximum session durations, in seconds, found
+	// by --assume-role-duration-probe for each role ARN.
+	RoleMaxDurations map[string]int32 `json:"role_max_durations,omitempty"`
+}
+
+// tempProfile is a profile that `cred temp-profile` wrote to a credentials
+// file, and that should be removed once it expires.
+type tempProfile struct {
+	Name    string    `json:"name"`
+	File    string    `json:"file"`
+	Expires time.Time `json:"expires"`
+}
+
+func statePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("Failed to locate cred's state file: %w", err)
+	}
+	return filepath.Join(dir, "cred", "state.json"), nil
+}
+
+// loadState reads cred's state file. A missing file is an empty state.
+func loadState() (*state, error) {
+	path, err := statePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &state{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read cred's state file: %w", err)
+	}
+
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("Failed to parse cred's state file %s: %w", path, err)
+	}
+
+	return &s, nil
+}
+
+func (s *state) save() error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("Failed to write cred's state file: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("Failed to write cred's state file: %w", err)
+	}
+
+	return nil
+}
+
+// writeFileIfChanged is writeFileAtomic, except that a file that already
+// contains data is left alone, so that its modification time does not wake up
+// tools watching it. --always-write rewrites it regardless.
+func writeFileIfChanged(path string, data []byte) error {
+	if !alwaysWrite {
+		if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, data) {
+			return nil
+		}
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic replaces the file at path with data, readable only by the
+// current user. Readers see either the old or the new contents, never a
+// partial write.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
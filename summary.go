@@ -0,0 +1,69 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+var printSummary bool
+
+// credentialKind is a short name for how the profile's credentials are
+// obtained, for the --summary line.
+func credentialKind(cfg aws.Config) string {
+	if specFile != "" {
+		return "spec"
+	}
+	if vaultPath != "" {
+		return "vault"
+	}
+	if accountMapFile != "" {
+		return "sso"
+	}
+
+	for _, src := range cfg.ConfigSources {
+		shared, ok := src.(config.SharedConfig)
+		if !ok {
+			continue
+		}
+		if shared.RoleARN != "" {
+			return "assume-role"
+		}
+		switch credentialSource(&shared) {
+		case "access keys":
+			return "static"
+		case "SSO":
+			return "sso"
+		case "credential_process":
+			return "credential-process"
+		case "web identity token":
+			return "web-identity"
+		}
+	}
+	return "default-chain"
+}
+
+// summaryLine describes the resolved credentials in a single line of
+// key=value pairs, for grepping CI logs. It contains no secrets.
+func summaryLine(res *resolution) string {
+	expires := "never"
+	if res.creds.CanExpire {
+		expires = res.creds.Expires.UTC().Format(time.RFC3339)
+	}
+
+	fields := []string{
+		"profile=" + cmp.Or(profile, "default"),
+		"account=" + res.account,
+		"region=" + cmp.Or(res.cfg.Region, "none"),
+		"expires=" + expires,
+		"source=" + credentialKind(res.cfg),
+	}
+	if n := accountName(res.account); n != "" {
+		fields = append(fields, "account_name="+n)
+	}
+	return fmt.Sprintf("cred: %s\n", strings.Join(fields, " "))
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/spf13/cobra"
+)
+
+var tempProfileName string
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.@+=,-]+$`)
+
+// credentialsFilePath returns the shared credentials file that the SDKs will
+// read, honoring AWS_SHARED_CREDENTIALS_FILE.
+func credentialsFilePath() string {
+	if awsDir != "" {
+		return filepath.Join(awsDir, "credentials")
+	}
+	if path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); path != "" {
+		return path
+	}
+	return config.DefaultSharedCredentialsFilename()
+}
+
+func readFileIfExists(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return string(data), err
+}
+
+// writeProfile writes values as the named profile in the credentials file at
+// path, replacing the profile if it already exists.
+func writeProfile(path, profileName string, values []variable) error {
+	contents, err := readFileIfExists(path)
+	if err != nil {
+		return fmt.Errorf("Failed to read credentials file: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("Failed to write credentials file: %w", err)
+	}
+
+	if err := writeFileAtomic(path, []byte(setSection(contents, profileName, values))); err != nil {
+		return fmt.Errorf("Failed to write credentials file: %w", err)
+	}
+
+	return nil
+}
+
+// profileValues are the credentials file settings for creds.
+func profileValues(creds aws.Credentials) []variable {
+	values := []variable{
+		{Name: "aws_access_key_id", Value: creds.AccessKeyID},
+		{Name: "aws_secret_access_key", Value: creds.SecretAccessKey},
+	}
+	if creds.SessionToken != "" {
+		values = append(values, variable{Name: "aws_session_token", Value: creds.SessionToken})
+	}
+	return values
+}
+
+// validateAlsoWrite checks the --also-write profile name before any
+// credentials are resolved. It must not be the profile the credentials come
+// from, or any of the --profile-fallback profiles, which would be replaced by
+// their own temporary credentials.
+func validateAlsoWrite() error {
+	if alsoWrite == "" {
+		return nil
+	}
+
+	if !profileNamePattern.MatchString(alsoWrite) {
+		return fmt.Errorf("Invalid --also-write profile name %q", alsoWrite)
+	}
+
+	sources := []string{cmp.Or(strings.TrimSpace(profile), envProfile(), "default")}
+	if len(profileFallback) > 0 {
+		sources = profileFallback
+	}
+	if slices.Contains(sources, alsoWrite) {
+		return fmt.Errorf("Refusing to overwrite profile %s with its own credentials", alsoWrite)
+	}
+
+	return nil
+}
+
+// removeProfile deletes the named profile from the credentials file at path.
+func removeProfile(path, profileName string) error {
+	contents, err := readFileIfExists(path)
+	if err != nil {
+		return fmt.Errorf("Failed to read credentials file: %w", err)
+	}
+
+	if !hasSection(contents, profileName) {
+		return nil
+	}
+
+	if err := writeFileAtomic(path, []byte(removeSection(contents, profileName))); err != nil {
+		return fmt.Errorf("Failed to write credentials file: %w", err)
+	}
+
+	return nil
+}
+
+// removeTempProfiles deletes the tracked temporary profiles for which remove
+// returns true, and stops tracking them.
+func (s *state) removeTempProfiles(remove func(tempProfile) bool) error {
+	kept := []tempProfile{}
+	for _, p := range s.TempProfiles {
+		if !remove(p) {
+			kept = append(kept, p)
+			continue
+		}
+		if err := removeProfile(p.File, p.Name); err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "Removed temporary profile %s from %s\n", p.Name, p.File)
+	}
+
+	s.TempProfiles = kept
+	return nil
+}
+
+func (s *state) isTempProfile(file, profileName string) bool {
+	for _, p := range s.TempProfiles {
+		if p.File == file && p.Name == profileName {
+			return true
+		}
+	}
+	return false
+}
+
+var tempProfileCmd = &cobra.Command{
+	Use:   "temp-profile",
+	Short: "Write temporary credentials to a profile in your credentials file",
+	Long:  wordwrap.WrapString("Write temporary credentials to a profile in your credentials file.\n\nThis is useful for tools that only understand profiles. Evaluate the output of the command in order to select the profile, e.g. eval $(cred temp-profile --name tmp). Temporary profiles that have expired are removed the next time this command runs, or all of them can be removed with `cred temp-profile clean`.", 80),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		if !profileNamePattern.MatchString(tempProfileName) {
+			return fmt.Errorf("Invalid profile name %q", tempProfileName)
+		}
+
+		s, err := loadState()
+		if err != nil {
+			return err
+		}
+
+		t := now.Now()
+		if err := s.removeTempProfiles(func(p tempProfile) bool { return t.After(p.Expires) }); err != nil {
+			return err
+		}
+
+		file := credentialsFilePath()
+		contents, err := readFileIfExists(file)
+		if err != nil {
+			return fmt.Errorf("Failed to read credentials file: %w", err)
+		}
+		if hasSection(contents, tempProfileName) && !s.isTempProfile(file, tempProfileName) {
+			return fmt.Errorf("Profile %s already exists in %s and was not created by cred", tempProfileName, file)
+		}
+
+		scope, err := readSessionScope(ctx)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig(ctx, scope)
+		if err != nil {
+			return err
+		}
+
+		creds, err := cfg.Credentials.Retrieve(ctx)
+		if err != nil {
+			return err
+		}
+
+		if creds.SessionToken == "" {
+			return fmt.Errorf("Refusing to write long-lived credentials to a temporary profile")
+		}
+
+		if _, err := getCallerIdentity(ctx, cfg); err != nil {
+			return err
+		}
+
+		if err := writeProfile(file, tempProfileName, profileValues(creds)); err != nil {
+			return err
+		}
+
+		kept := []tempProfile{}
+		for _, p := range s.TempProfiles {
+			if p.File != file || p.Name != tempProfileName {
+				kept = append(kept, p)
+			}
+		}
+		s.TempProfiles = append(kept, tempProfile{Name: tempProfileName, File: file, Expires: creds.Expires})
+		if err := s.save(); err != nil {
+			return err
+		}
+
+		// Credential variables take precedence over AWS_PROFILE, so any that
+		// are set would hide the profile.
+		fmt.Print(script(
+			[]variable{{Name: "AWS_PROFILE", Value: tempProfileName}},
+			[]string{accessKeyID, secretAccessKey, sessionToken, securityToken},
+		))
+		return nil
+	},
+}
+
+var tempProfileCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove all temporary profiles written by cred",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := loadState()
+		if err != nil {
+			return err
+		}
+
+		if err := s.removeTempProfiles(func(p tempProfile) bool { return true }); err != nil {
+			return err
+		}
+
+		return s.save()
+	},
+}
+
+func init() {
+	addCredentialFlags(tempProfileCmd)
+	tempProfileCmd.Flags().StringVar(&tempProfileName, "name", "", "Name of the profile to write")
+	tempProfileCmd.MarkFlagRequired("name")
+
+	tempProfileCmd.AddCommand(tempProfileCleanCmd)
+	rootCmd.AddCommand(tempProfileCmd)
+}
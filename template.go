@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// templateData is the data model passed to a --format-template.
+type templateData struct {
+	// Credentials are the resolved AWS credentials. Empty for `cred clear`.
+	Credentials aws.Credentials
+	// AccountID is the AWS account the credentials belong to.
+	AccountID string
+	// AccountAlias is the account's alias, if --account-alias was used.
+	AccountAlias string
+	// Region is the resolved AWS region, if any.
+	Region string
+	// Sets are the variables that should be set, in output order.
+	Sets []variable
+	// Unsets are the names of the variables that should be unset.
+	Unsets []string
+}
+
+// loadTemplate reads and parses the template file at path, so that a broken
+// template is reported before any credentials are fetched.
+func loadTemplate(path string) (*template.Template, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read format template: %w", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse format template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// renderTemplate executes tmpl with data.
+func renderTemplate(tmpl *template.Template, data templateData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("Failed to execute format template: %w", err)
+	}
+	return buf.String(), nil
+}
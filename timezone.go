@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+var displayTZ string
+
+func validateTZ() error {
+	if displayTZ != "local" && displayTZ != "utc" {
+		return fmt.Errorf("Invalid --tz %q: must be local or utc", displayTZ)
+	}
+	return nil
+}
+
+// displayTime converts t to the time zone chosen with --tz, for showing to
+// people. Exported variables are always UTC.
+func displayTime(t time.Time) time.Time {
+	if displayTZ == "utc" {
+		return t.UTC()
+	}
+	return t.Local()
+}
+
+// displayClock formats t as a time of day in the time zone chosen with --tz.
+// UTC is marked as such, since it is rarely the zone people expect.
+func displayClock(t time.Time) string {
+	if displayTZ == "utc" {
+		return t.UTC().Format("15:04 UTC")
+	}
+	return t.Local().Format("15:04")
+}
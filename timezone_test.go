@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestValidateTZ(t *testing.T) {
+	oldTZ := displayTZ
+	t.Cleanup(func() { displayTZ = oldTZ })
+
+	for _, tz := range []string{"local", "utc"} {
+		displayTZ = tz
+		if err := validateTZ(); err != nil {
+			t.Errorf("--tz %s: unexpected error: %v", tz, err)
+		}
+	}
+	for _, tz := range []string{"", "UTC", "America/New_York"} {
+		displayTZ = tz
+		if err := validateTZ(); err == nil {
+			t.Errorf("--tz %q: expected an error", tz)
+		}
+	}
+}
+
+func TestDisplayTZ(t *testing.T) {
+	oldLocal, oldTZ := time.Local, displayTZ
+	t.Cleanup(func() { time.Local, displayTZ = oldLocal, oldTZ })
+	time.Local = time.FixedZone("EST", -5*60*60)
+
+	// Keep account names from the user's own config out of the comment.
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	setClock(t, at)
+	res := &resolution{
+		creds:   aws.Credentials{CanExpire: true, Expires: now.Now().Add(90 * time.Minute)},
+		account: fakeAccount,
+		arn:     "arn:aws:sts::123456789012:assumed-role/Admin/alice",
+	}
+
+	tests := []struct {
+		tz          string
+		wantTime    string
+		wantClock   string
+		wantComment string
+	}{
+		{
+			tz:          "local",
+			wantTime:    "Fri, 01 Mar 2024 08:30:00 EST",
+			wantClock:   "08:30",
+			wantComment: "# expires 08:30, account 123456789012, assumed-role/Admin/alice\n",
+		},
+		{
+			tz:          "utc",
+			wantTime:    "Fri, 01 Mar 2024 13:30:00 UTC",
+			wantClock:   "13:30 UTC",
+			wantComment: "# expires 13:30 UTC, account 123456789012, assumed-role/Admin/alice\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tz, func(t *testing.T) {
+			displayTZ = tt.tz
+
+			if got := displayTime(res.creds.Expires).Format(time.RFC1123); got != tt.wantTime {
+				t.Errorf("displayTime: got %q, want %q", got, tt.wantTime)
+			}
+			if got := displayClock(res.creds.Expires); got != tt.wantClock {
+				t.Errorf("displayClock: got %q, want %q", got, tt.wantClock)
+			}
+			if got := summaryComment(res); got != tt.wantComment {
+				t.Errorf("summaryComment: got %q, want %q", got, tt.wantComment)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/spf13/cobra"
+)
+
+// tmuxSetEnvironment runs `tmux set-environment` with args, in the tmux
+// session cred is running in.
+func tmuxSetEnvironment(args ...string) error {
+	out, err := exec.Command("tmux", append([]string{"set-environment"}, args...)...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("Failed to set the tmux environment: %s", msg)
+		}
+		return fmt.Errorf("Failed to set the tmux environment: %w", err)
+	}
+	return nil
+}
+
+var tmuxEnvCmd = &cobra.Command{
+	Use:   "tmux-env",
+	Short: "Set AWS credentials in the environment of the current tmux session",
+	Long:  wordwrap.WrapString("Set AWS credentials in the environment of the current tmux session, so that every new pane and window inherits them.\n\nPanes that are already open keep their environment. Variables that cred cannot resolve are removed from the session environment, so new panes do not inherit them from the global environment either.", 80),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if os.Getenv("TMUX") == "" {
+			return fmt.Errorf("TMUX is not set; run this from inside a tmux session")
+		}
+
+		res, err := resolve(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		for _, v := range res.exports {
+			if err := tmuxSetEnvironment(v.Name, v.Value); err != nil {
+				return err
+			}
+		}
+		for _, n := range res.unsets {
+			if err := tmuxSetEnvironment("-r", n); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(stderr, "Set %d variables in the tmux session; new panes will inherit them\n", len(res.exports))
+		return nil
+	},
+}
+
+func init() {
+	addCredentialFlags(tmuxEnvCmd)
+	tmuxEnvCmd.Flags().BoolVar(&printSummary, "summary", false, "Print a one-line summary of the resolved credentials to stderr, without secrets")
+	tmuxEnvCmd.Flags().StringVar(&traceOut, "trace-out", "", "Write a JSON timing summary of resolving the credentials and each AWS call to this file, without secrets")
+
+	rootCmd.AddCommand(tmuxEnvCmd)
+}
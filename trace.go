@@ -0,0 +1,114 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/smithy-go/middleware"
+)
+
+var traceOut string
+
+// span is the timing summary written to --trace-out, loosely modelled on an
+// OpenTelemetry span with a child for each AWS call. It contains no secrets.
+type span struct {
+	Name       string            `json:"name"`
+	StartTime  time.Time         `json:"start_time"`
+	EndTime    time.Time         `json:"end_time"`
+	DurationMS int64             `json:"duration_ms"`
+	Status     string            `json:"status"`
+	Error      string            `json:"error_category,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Calls      []callSpan        `json:"calls"`
+}
+
+type callSpan struct {
+	Service    string `json:"service"`
+	Operation  string `json:"operation"`
+	DurationMS int64  `json:"duration_ms"`
+	Status     string `json:"status"`
+}
+
+// tracer collects the AWS calls made while resolving credentials.
+var tracer struct {
+	mu    sync.Mutex
+	calls []callSpan
+}
+
+// traceOptions returns load options that time every AWS call, including
+// those the SDK makes to resolve the profile's credentials.
+func traceOptions() []func(*config.LoadOptions) error {
+	if traceOut == "" {
+		return nil
+	}
+
+	timing := middleware.InitializeMiddlewareFunc("CredTrace", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+		start := time.Now()
+		out, md, err := next.HandleInitialize(ctx, in)
+
+		call := callSpan{
+			Service:    awsmiddleware.GetServiceID(ctx),
+			Operation:  awsmiddleware.GetOperationName(ctx),
+			DurationMS: time.Since(start).Milliseconds(),
+			Status:     "ok",
+		}
+		if err != nil {
+			call.Status = "error"
+		}
+
+		tracer.mu.Lock()
+		tracer.calls = append(tracer.calls, call)
+		tracer.mu.Unlock()
+
+		return out, md, err
+	})
+
+	return []func(*config.LoadOptions) error{config.WithAPIOptions([]func(*middleware.Stack) error{
+		func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(timing, middleware.After)
+		},
+	})}
+}
+
+// writeTrace writes the span for resolving credentials from start until now
+// to the --trace-out file.
+func writeTrace(start time.Time, res *resolution, err error) error {
+	end := time.Now()
+
+	tracer.mu.Lock()
+	calls := append([]callSpan{}, tracer.calls...)
+	tracer.mu.Unlock()
+
+	s := span{
+		Name:       "cred.resolve",
+		StartTime:  start.UTC(),
+		EndTime:    end.UTC(),
+		DurationMS: end.Sub(start).Milliseconds(),
+		Status:     "ok",
+		Attributes: map[string]string{"profile": cmp.Or(profile, "default")},
+		Calls:      calls,
+	}
+	if err != nil {
+		s.Status = "error"
+		s.Error = errorCategory(err)
+	} else if !res.unchanged {
+		s.Attributes["account"] = res.account
+		s.Attributes["region"] = res.cfg.Region
+		s.Attributes["source"] = credentialKind(res.cfg)
+	}
+
+	data, jsonErr := json.Marshal(s)
+	if jsonErr != nil {
+		return jsonErr
+	}
+	if err := writeFileAtomic(traceOut, append(data, '\n')); err != nil {
+		return fmt.Errorf("Failed to write --trace-out file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/spf13/cobra"
+)
+
+var vaultPath string
+
+// vaultSecret is the response of Vault's AWS secrets engine to a read of its
+// creds or sts endpoints, or the errors it returned instead.
+type vaultSecret struct {
+	LeaseDuration int `json:"lease_duration"`
+	Data          struct {
+		AccessKey     string `json:"access_key"`
+		SecretKey     string `json:"secret_key"`
+		SecurityToken string `json:"security_token"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// vaultToken returns VAULT_TOKEN, or the token that `vault login` saved.
+func vaultToken() (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".vault-token"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("VAULT_TOKEN is not set and there is no ~/.vault-token; run vault login")
+	}
+	if err != nil {
+		return "", fmt.Errorf("Failed to read ~/.vault-token: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readVault reads AWS credentials from the secret at path in the Vault server
+// at VAULT_ADDR. Credentials expire when their lease does.
+func readVault(ctx context.Context, path string) (aws.Credentials, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return aws.Credentials{}, fmt.Errorf("VAULT_ADDR is not set")
+	}
+	token, err := vaultToken()
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+
+	u, err := url.JoinPath(addr, "v1", strings.Trim(path, "/"))
+	if err != nil {
+		return aws.Credentials{}, fmt.Errorf("Invalid VAULT_ADDR %q: %w", addr, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	start := now.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return aws.Credentials{}, fmt.Errorf("Failed to read %s from Vault: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	var secret vaultSecret
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil && resp.StatusCode == http.StatusOK {
+		return aws.Credentials{}, fmt.Errorf("Failed to parse the Vault response for %s: %w", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg := strings.Join(secret.Errors, "; ")
+		if msg == "" {
+			msg = resp.Status
+		}
+		return aws.Credentials{}, fmt.Errorf("Vault refused to read %s: %s", path, msg)
+	}
+	if secret.Data.AccessKey == "" || secret.Data.SecretKey == "" {
+		return aws.Credentials{}, fmt.Errorf("The Vault secret at %s does not contain AWS credentials", path)
+	}
+
+	creds := aws.Credentials{
+		AccessKeyID:     secret.Data.AccessKey,
+		SecretAccessKey: secret.Data.SecretKey,
+		SessionToken:    secret.Data.SecurityToken,
+		Source:          "Vault",
+	}
+	if secret.LeaseDuration > 0 {
+		creds.CanExpire = true
+		creds.Expires = start.Add(time.Duration(secret.LeaseDuration) * time.Second)
+	}
+	return creds, nil
+}
+
+// vaultPropagationTimeout bounds how long cred waits for IAM to accept the
+// keys of a new IAM user from Vault.
+const vaultPropagationTimeout = 30 * time.Second
+
+// awaitVaultCredentials waits until STS accepts the long-lived keys of an
+// iam_user secret from Vault. Vault creates a new IAM user for each read, and
+// until IAM has propagated it, which takes several seconds, STS rejects its
+// keys with InvalidClientTokenId. Session credentials work straight away. If
+// the keys are still rejected, whatever uses them next reports it.
+func awaitVaultCredentials(ctx context.Context, cfg aws.Config, creds aws.Credentials) {
+	if creds.SessionToken != "" {
+		return
+	}
+
+	cfg = cfg.Copy()
+	cfg.Credentials = credentials.StaticCredentialsProvider{Value: creds}
+	client := sts.NewFromConfig(cfg)
+	deadline := time.Now().Add(vaultPropagationTimeout)
+	for delay := time.Second; ; delay = min(2*delay, 5*time.Second) {
+		_, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		var apiErr smithy.APIError
+		if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "InvalidClientTokenId" || time.Now().Add(delay).After(deadline) {
+			return
+		}
+		if delay == time.Second {
+			fmt.Fprintln(stderr, "Waiting for IAM to accept the new credentials from Vault")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// vaultOptions returns load options that get credentials from the Vault
+// secret at path, instead of from the profile. Credentials are only read once
+// the config is loaded, so that the propagation of new IAM users can be
+// checked with cfg, which must be set to the loaded config.
+func vaultOptions(path string, cfg *aws.Config) []func(*config.LoadOptions) error {
+	provider := aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		creds, err := readVault(ctx, path)
+		if err != nil {
+			return aws.Credentials{}, err
+		}
+		awaitVaultCredentials(ctx, *cfg, creds)
+		return creds, nil
+	})
+	return []func(*config.LoadOptions) error{config.WithCredentialsProvider(aws.NewCredentialsCache(provider))}
+}
+
+var vaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "Fetch AWS credentials from HashiCorp Vault's AWS secrets engine",
+	Long:  wordwrap.WrapString("Fetch AWS credentials from HashiCorp Vault's AWS secrets engine and set them as environment variables.\n\nThe credentials are read from --path, e.g. aws/creds/my-role, in the Vault server at VAULT_ADDR, with the token in VAULT_TOKEN or ~/.vault-token. They expire when their lease does. The profile, if any, only supplies the region. Evaluate the output as with cred itself, e.g. eval $(cred vault --path aws/creds/my-role).", 80),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return rootCmd.RunE(cmd, args)
+	},
+}
+
+func init() {
+	addCredentialFlags(vaultCmd)
+	addGuardFlags(vaultCmd)
+	vaultCmd.Flags().StringVar(&formatTemplate, "format-template", "", "Path to a Go text/template file used to render the output")
+	addFormatFlags(vaultCmd)
+	vaultCmd.Flags().StringVar(&vaultPath, "path", "", "Path of the Vault secret to read, e.g. aws/creds/my-role")
+	vaultCmd.MarkFlagRequired("path")
+	vaultCmd.MarkFlagsMutuallyExclusive("path", "account-map")
+
+	rootCmd.AddCommand(vaultCmd)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeVault serves an iam_user secret at aws/creds/dev, and points cred at
+// it.
+func fakeVault(t *testing.T) {
+	t.Helper()
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/aws/creds/dev" || r.Header.Get("X-Vault-Token") != "fake-vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, `{"errors": ["permission denied"]}`)
+			return
+		}
+		io.WriteString(w, `{"lease_duration": 3600, "data": {"access_key": "AKIDVAULT", "secret_key": "fake-vault-secret"}}`)
+	}))
+	t.Cleanup(vault.Close)
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_TOKEN", "fake-vault-token")
+}
+
+func TestVaultIAMUserPropagation(t *testing.T) {
+	fakeVault(t)
+
+	// IAM rejects the new user's keys the first time they are used.
+	f := newFakeAWS(t)
+	f.handle["GetCallerIdentity"] = func(r *http.Request) (int, string) {
+		if f.called("GetCallerIdentity") == 1 {
+			return http.StatusForbidden, fakeError("InvalidClientTokenId", "The security token included in the request is invalid.")
+		}
+		return http.StatusOK, fakeResponses["GetCallerIdentity"]
+	}
+
+	out, err := runCred(t, f, "vault", "--path", "aws/creds/dev")
+	if err != nil {
+		t.Fatalf("unexpected error %v, stderr:\n%s", err, out)
+	}
+	if !strings.Contains(out, "Waiting for IAM to accept the new credentials from Vault") {
+		t.Errorf("did not report waiting, stderr:\n%s", out)
+	}
+	if got := f.called("GetCallerIdentity"); got != 3 {
+		t.Errorf("GetCallerIdentity was called %d times, want 3", got)
+	}
+	if strings.Contains(out, "fake-vault-secret") {
+		t.Errorf("stderr contains the secret:\n%s", out)
+	}
+}
+
+// TestVaultSourceProfileRegion checks that cred waits for the keys in the
+// region of the profile's source_profile when the profile has none.
+func TestVaultSourceProfileRegion(t *testing.T) {
+	fakeVault(t)
+
+	f := newFakeAWS(t)
+	regions := []string{}
+	f.handle["GetCallerIdentity"] = func(r *http.Request) (int, string) {
+		_, scope, _ := strings.Cut(r.Header.Get("Authorization"), "Credential=")
+		regions = append(regions, strings.Split(scope, "/")[2])
+		return http.StatusOK, fakeResponses["GetCallerIdentity"]
+	}
+
+	out, err := runCred(t, f, "vault", "--path", "aws/creds/dev", "--profile", "jump")
+	if err != nil {
+		t.Fatalf("unexpected error %v, stderr:\n%s", err, out)
+	}
+	if want := []string{"us-east-1", "us-east-1"}; !slices.Equal(regions, want) {
+		t.Errorf("GetCallerIdentity was called in %q, want %q", regions, want)
+	}
+}